@@ -0,0 +1,173 @@
+package common
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	pqcommon "github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// rowReader reads the rows of a data file as the text of their cells, nil for NULL.
+type rowReader interface {
+	Columns() []string
+	// Read returns the next row or io.EOF.
+	Read() ([]*string, error)
+	Close() error
+}
+
+// openRows opens a Parquet file by its .parquet extension, and any other file as CSV
+// whose first line names the columns. Empty CSV cells are NULL.
+func openRows(file string) (rowReader, error) {
+	var (
+		rows rowReader
+		err  error
+	)
+	if strings.EqualFold(filepath.Ext(file), ".parquet") {
+		rows, err = newParquetRows(file)
+	} else {
+		rows, err = newCSVRows(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return rows, nil
+}
+
+type csvRows struct {
+	*os.File
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVRows(file string) (*csvRows, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(f)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to read the header: %w", err)
+	}
+	return &csvRows{File: f, reader: reader, columns: append([]string(nil), header...)}, nil
+}
+
+func (r *csvRows) Columns() []string {
+	return r.columns
+}
+
+func (r *csvRows) Read() ([]*string, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]*string, len(record))
+	for i, cell := range record {
+		if cell != "" {
+			cell := cell
+			row[i] = &cell
+		}
+	}
+	return row, nil
+}
+
+// parquetRowsChunk is the number of rows read from every column of a Parquet file at once.
+const parquetRowsChunk = 1000
+
+type parquetRows struct {
+	source.ParquetFile
+	reader  *reader.ParquetReader
+	columns []string
+	left    int64
+	buf     [][]*string
+}
+
+func newParquetRows(file string) (*parquetRows, error) {
+	f, err := local.NewLocalFileReader(file)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := reader.NewParquetColumnReader(f, 1)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	var columns []string
+	for i, element := range pr.SchemaHandler.SchemaElements {
+		if element.GetNumChildren() != 0 {
+			continue
+		}
+		if path := pqcommon.StrToPath(pr.SchemaHandler.IndexMap[int32(i)]); len(path) != 2 {
+			_ = f.Close()
+			return nil, fmt.Errorf("nested column %s is not supported", strings.Join(path[1:], "."))
+		}
+		columns = append(columns, pr.SchemaHandler.GetExName(i))
+	}
+	return &parquetRows{ParquetFile: f, reader: pr, columns: columns, left: pr.GetNumRows()}, nil
+}
+
+func (r *parquetRows) Columns() []string {
+	return r.columns
+}
+
+func (r *parquetRows) Read() ([]*string, error) {
+	if len(r.buf) == 0 {
+		if err := r.readChunk(); err != nil {
+			return nil, err
+		}
+	}
+	row := r.buf[0]
+	r.buf = r.buf[1:]
+	return row, nil
+}
+
+// readChunk reads the next rows column by column.
+func (r *parquetRows) readChunk() error {
+	n := min(r.left, parquetRowsChunk)
+	if n == 0 {
+		return io.EOF
+	}
+	rows := make([][]*string, n)
+	for i := range rows {
+		rows[i] = make([]*string, len(r.columns))
+	}
+	for column := range r.columns {
+		values, _, _, err := r.reader.ReadColumnByIndex(int64(column), n)
+		if err != nil {
+			return err
+		}
+		if int64(len(values)) != n {
+			return fmt.Errorf("column %s has %d values instead of %d", r.columns[column], len(values), n)
+		}
+		for i, value := range values {
+			if value != nil {
+				cell := parquetCell(value)
+				rows[i][column] = &cell
+			}
+		}
+	}
+	r.left -= n
+	r.buf = rows
+	return nil
+}
+
+func parquetCell(value interface{}) string {
+	switch v := value.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
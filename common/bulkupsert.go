@@ -0,0 +1,306 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+// BulkUpsertAnnotation loads a CSV or Parquet file into a table after the Up statements of the migration, e.g.
+// `-- +goose bulkupsert table=/ru-central1/b1g/etn/geo file=data/geo.csv batch_size=5000`.
+const BulkUpsertAnnotation = "bulkupsert"
+
+// DefaultBulkUpsertBatchSize is the number of rows written by a single BulkUpsert call.
+const DefaultBulkUpsertBatchSize = 1000
+
+// BulkLoad is a data file loaded into a table by a bulkupsert annotation.
+type BulkLoad struct {
+	Table string
+	// File is the path of the data file. Relative paths in the annotation are relative to the migration file.
+	File      string
+	BatchSize int
+}
+
+// BulkLoads returns the bulkupsert annotations of the migration file source.
+func (m *SQLMigration) BulkLoads(source string) ([]BulkLoad, error) {
+	var loads []BulkLoad
+	for _, annotation := range m.Annotations {
+		fields := strings.Fields(annotation)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], BulkUpsertAnnotation) {
+			continue
+		}
+
+		load := BulkLoad{BatchSize: DefaultBulkUpsertBatchSize}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "table":
+				load.Table = value
+			case "file":
+				load.File = value
+				if !filepath.IsAbs(value) {
+					load.File = filepath.Join(filepath.Dir(source), value)
+				}
+			case "batch_size":
+				size, err := strconv.Atoi(value)
+				if err != nil || size <= 0 {
+					return nil, fmt.Errorf("%s: batch_size must be a positive number, got %q", source, value)
+				}
+				load.BatchSize = size
+			default:
+				return nil, fmt.Errorf("%s: unknown bulkupsert option %q, expected table, file or batch_size", source, key)
+			}
+		}
+		if load.Table == "" || load.File == "" {
+			return nil, fmt.Errorf("%s: bulkupsert requires table=<path> and file=<path>", source)
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
+
+// BulkUpserter writes rows into a table outside of transactions.
+type BulkUpserter interface {
+	// BulkUpsert writes the rows given as the text of their cells, nil for NULL.
+	BulkUpsert(ctx context.Context, table string, columns []string, rows [][]*string) error
+}
+
+var openBulkUpserter = openYDBBulkUpserter
+
+func openYDBBulkUpserter(db *sql.DB) (BulkUpserter, error) {
+	driver, err := ydb.Unwrap(db)
+	if err != nil {
+		return nil, fmt.Errorf("bulkupsert requires a YDB database: %w", err)
+	}
+	return &ydbBulkUpserter{driver: driver, columns: map[string]map[string]types.Type{}}, nil
+}
+
+// runBulkLoads streams the data files into their tables in batches, logging the progress.
+// It stops between the batches once ctx is done, e.g. when the resource timeout expires.
+func runBulkLoads(ctx context.Context, db *sql.DB, loads []BulkLoad) error {
+	if len(loads) == 0 {
+		return nil
+	}
+	upserter, err := openBulkUpserter(db)
+	if err != nil {
+		return err
+	}
+	for _, load := range loads {
+		if err := runBulkLoad(ctx, upserter, load); err != nil {
+			return fmt.Errorf("bulkupsert %s into %s: %w", load.File, load.Table, err)
+		}
+	}
+	return nil
+}
+
+func runBulkLoad(ctx context.Context, upserter BulkUpserter, load BulkLoad) error {
+	rows, err := openRows(load.File)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	start := time.Now()
+	total := 0
+	batch := make([][]*string, 0, load.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d rows: %w", total, err)
+		}
+		if err := upserter.BulkUpsert(ctx, load.Table, rows.Columns(), batch); err != nil {
+			return fmt.Errorf("rows %d-%d: %w", total+1, total+len(batch), err)
+		}
+		total += len(batch)
+		batch = batch[:0]
+		tflog.Info(ctx, fmt.Sprintf("goose: bulk upserted %d rows of %s into %s", total, load.File, load.Table), map[string]interface{}{
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+		return nil
+	}
+
+	for {
+		row, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %w", total+len(batch)+1, err)
+		}
+		batch = append(batch, row)
+		if len(batch) == load.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+type ydbBulkUpserter struct {
+	driver *ydb.Driver
+	// columns caches the column types by table.
+	columns map[string]map[string]types.Type
+}
+
+func (u *ydbBulkUpserter) BulkUpsert(ctx context.Context, tablePath string, columns []string, rows [][]*string) error {
+	if !strings.HasPrefix(tablePath, "/") {
+		tablePath = path.Join(u.driver.Name(), tablePath)
+	}
+	columnTypes, err := u.columnTypes(ctx, tablePath)
+	if err != nil {
+		return err
+	}
+
+	values := make([]types.Value, 0, len(rows))
+	for i, row := range rows {
+		fields := make([]types.StructValueOption, 0, len(columns))
+		for j, column := range columns {
+			t, ok := columnTypes[column]
+			if !ok {
+				return fmt.Errorf("table %s has no column %q", tablePath, column)
+			}
+			var cell *string
+			if j < len(row) {
+				cell = row[j]
+			}
+			value, err := ydbValue(t, cell)
+			if err != nil {
+				return fmt.Errorf("row %d, column %s: %w", i+1, column, err)
+			}
+			fields = append(fields, types.StructFieldValue(column, value))
+		}
+		values = append(values, types.StructValue(fields...))
+	}
+
+	return u.driver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		return s.BulkUpsert(ctx, tablePath, types.ListValue(values...))
+	}, table.WithIdempotent())
+}
+
+func (u *ydbBulkUpserter) columnTypes(ctx context.Context, tablePath string) (map[string]types.Type, error) {
+	if columns, ok := u.columns[tablePath]; ok {
+		return columns, nil
+	}
+	columns := map[string]types.Type{}
+	err := u.driver.Table().Do(ctx, func(ctx context.Context, s table.Session) error {
+		desc, err := s.DescribeTable(ctx, tablePath)
+		if err != nil {
+			return err
+		}
+		for _, column := range desc.Columns {
+			columns[column.Name] = column.Type
+		}
+		return nil
+	}, table.WithIdempotent())
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", tablePath, err)
+	}
+	u.columns[tablePath] = columns
+	return columns, nil
+}
+
+// ydbValue converts the text of a cell to a value of the column type. Date, Datetime, Timestamp and Interval
+// accept either the number of days, seconds or microseconds YDB stores, or a date, an RFC 3339 time
+// or a Go duration respectively.
+func ydbValue(t types.Type, cell *string) (types.Value, error) {
+	if optional, inner := types.IsOptional(t); optional {
+		if cell == nil {
+			return types.NullValue(inner), nil
+		}
+		value, err := ydbValue(inner, cell)
+		if err != nil {
+			return nil, err
+		}
+		return types.OptionalValue(value), nil
+	}
+	if cell == nil {
+		return nil, fmt.Errorf("NULL in a column of type %s", t.Yql())
+	}
+
+	s := strings.TrimSpace(*cell)
+	switch {
+	case types.Equal(t, types.TypeUTF8):
+		return types.TextValue(*cell), nil
+	case types.Equal(t, types.TypeString):
+		return types.BytesValueFromString(*cell), nil
+	case types.Equal(t, types.TypeJSON):
+		return types.JSONValue(*cell), nil
+	case types.Equal(t, types.TypeJSONDocument):
+		return types.JSONDocumentValue(*cell), nil
+	case types.Equal(t, types.TypeYSON):
+		return types.YSONValue(*cell), nil
+	case types.Equal(t, types.TypeBool):
+		v, err := strconv.ParseBool(s)
+		return types.BoolValue(v), err
+	case types.Equal(t, types.TypeInt8):
+		v, err := strconv.ParseInt(s, 10, 8)
+		return types.Int8Value(int8(v)), err
+	case types.Equal(t, types.TypeInt16):
+		v, err := strconv.ParseInt(s, 10, 16)
+		return types.Int16Value(int16(v)), err
+	case types.Equal(t, types.TypeInt32):
+		v, err := strconv.ParseInt(s, 10, 32)
+		return types.Int32Value(int32(v)), err
+	case types.Equal(t, types.TypeInt64):
+		v, err := strconv.ParseInt(s, 10, 64)
+		return types.Int64Value(v), err
+	case types.Equal(t, types.TypeUint8):
+		v, err := strconv.ParseUint(s, 10, 8)
+		return types.Uint8Value(uint8(v)), err
+	case types.Equal(t, types.TypeUint16):
+		v, err := strconv.ParseUint(s, 10, 16)
+		return types.Uint16Value(uint16(v)), err
+	case types.Equal(t, types.TypeUint32):
+		v, err := strconv.ParseUint(s, 10, 32)
+		return types.Uint32Value(uint32(v)), err
+	case types.Equal(t, types.TypeUint64):
+		v, err := strconv.ParseUint(s, 10, 64)
+		return types.Uint64Value(v), err
+	case types.Equal(t, types.TypeFloat):
+		v, err := strconv.ParseFloat(s, 32)
+		return types.FloatValue(float32(v)), err
+	case types.Equal(t, types.TypeDouble):
+		v, err := strconv.ParseFloat(s, 64)
+		return types.DoubleValue(v), err
+	case types.Equal(t, types.TypeDate):
+		if days, err := strconv.ParseUint(s, 10, 32); err == nil {
+			return types.DateValue(uint32(days)), nil
+		}
+		v, err := time.Parse(time.DateOnly, s)
+		return types.DateValueFromTime(v), err
+	case types.Equal(t, types.TypeDatetime):
+		if seconds, err := strconv.ParseUint(s, 10, 32); err == nil {
+			return types.DatetimeValue(uint32(seconds)), nil
+		}
+		v, err := time.Parse(time.RFC3339, s)
+		return types.DatetimeValueFromTime(v), err
+	case types.Equal(t, types.TypeTimestamp):
+		if micros, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return types.TimestampValue(micros), nil
+		}
+		v, err := time.Parse(time.RFC3339Nano, s)
+		return types.TimestampValueFromTime(v), err
+	case types.Equal(t, types.TypeInterval):
+		if micros, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return types.IntervalValueFromMicroseconds(micros), nil
+		}
+		v, err := time.ParseDuration(s)
+		return types.IntervalValueFromDuration(v), err
+	}
+	return nil, fmt.Errorf("bulkupsert doesn't support columns of type %s", t.Yql())
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+type fakeBulkUpserter struct {
+	table   string
+	columns []string
+	batches [][][]*string
+	err     error
+}
+
+func (u *fakeBulkUpserter) BulkUpsert(_ context.Context, table string, columns []string, rows [][]*string) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.table, u.columns = table, columns
+	u.batches = append(u.batches, append([][]*string(nil), rows...))
+	return nil
+}
+
+func (u *fakeBulkUpserter) cells() [][]string {
+	var cells [][]string
+	for _, batch := range u.batches {
+		var rows []string
+		for _, row := range batch {
+			var cols []string
+			for _, cell := range row {
+				if cell == nil {
+					cols = append(cols, "NULL")
+				} else {
+					cols = append(cols, *cell)
+				}
+			}
+			rows = append(rows, strings.Join(cols, ","))
+		}
+		cells = append(cells, rows)
+	}
+	return cells
+}
+
+func TestBulkLoads(t *testing.T) {
+	parsed, err := ParseSQLMigration(strings.NewReader("-- +goose Up\n-- +goose bulkupsert table=/db/geo file=data/geo.csv batch_size=2\nCREATE TABLE geo (id Uint64, PRIMARY KEY (id));\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads, err := parsed.BulkLoads("migrations/001_geo.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BulkLoad{{Table: "/db/geo", File: filepath.Join("migrations", "data", "geo.csv"), BatchSize: 2}}
+	if !reflect.DeepEqual(loads, want) {
+		t.Errorf("BulkLoads() = %+v, want %+v", loads, want)
+	}
+
+	for _, annotation := range []string{"bulkupsert table=/db/geo", "bulkupsert table=/db/geo file=geo.csv batch_size=0", "bulkupsert table=/db/geo file=geo.csv rows=1"} {
+		parsed := &SQLMigration{Annotations: []string{annotation}}
+		if _, err := parsed.BulkLoads("001_geo.sql"); err == nil {
+			t.Errorf("BulkLoads(%q) succeeded, want an error", annotation)
+		}
+	}
+}
+
+func TestRunBulkLoadCSV(t *testing.T) {
+	dir := writeSQLMigrations(t, map[string]string{
+		"geo.csv": "id,name\n1,Moscow\n2,\n3,\"Nizhny Novgorod\"\n",
+	})
+	upserter := &fakeBulkUpserter{}
+	err := runBulkLoad(context.Background(), upserter, BulkLoad{Table: "geo", File: filepath.Join(dir, "geo.csv"), BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upserter.table != "geo" || !reflect.DeepEqual(upserter.columns, []string{"id", "name"}) {
+		t.Errorf("BulkUpsert() table = %s, columns = %v", upserter.table, upserter.columns)
+	}
+	want := [][]string{{"1,Moscow", "2,NULL"}, {"3,Nizhny Novgorod"}}
+	if got := upserter.cells(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BulkUpsert() batches = %q, want %q", got, want)
+	}
+}
+
+func TestRunBulkLoadParquet(t *testing.T) {
+	type city struct {
+		ID         int64    `parquet:"name=id, type=INT64"`
+		Name       *string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+		Population *float64 `parquet:"name=population, type=DOUBLE, repetitiontype=OPTIONAL"`
+	}
+	file := filepath.Join(t.TempDir(), "geo.parquet")
+	fw, err := local.NewLocalFileWriter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, err := writer.NewParquetWriter(fw, new(city), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, population := "Moscow", 13.1
+	for _, c := range []city{{ID: 1, Name: &name, Population: &population}, {ID: 2}} {
+		if err := pw.Write(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	upserter := &fakeBulkUpserter{}
+	if err := runBulkLoad(context.Background(), upserter, BulkLoad{Table: "geo", File: file, BatchSize: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(upserter.columns, []string{"id", "name", "population"}) {
+		t.Errorf("BulkUpsert() columns = %v", upserter.columns)
+	}
+	want := [][]string{{"1,Moscow,13.1", "2,NULL,NULL"}}
+	if got := upserter.cells(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BulkUpsert() batches = %q, want %q", got, want)
+	}
+}
+
+func TestYDBValue(t *testing.T) {
+	cell := func(s string) *string { return &s }
+	tests := []struct {
+		t    types.Type
+		cell *string
+		want types.Value
+	}{
+		{t: types.TypeUint64, cell: cell("42"), want: types.Uint64Value(42)},
+		{t: types.Optional(types.TypeUTF8), cell: nil, want: types.NullValue(types.TypeUTF8)},
+		{t: types.Optional(types.TypeUTF8), cell: cell("Moscow"), want: types.OptionalValue(types.TextValue("Moscow"))},
+		{t: types.TypeDate, cell: cell("1970-01-11"), want: types.DateValue(10)},
+		{t: types.TypeTimestamp, cell: cell("1000000"), want: types.TimestampValue(1000000)},
+	}
+	for _, tt := range tests {
+		got, err := ydbValue(tt.t, tt.cell)
+		if err != nil {
+			t.Errorf("ydbValue(%s) error = %v", tt.t.Yql(), err)
+			continue
+		}
+		if got.Yql() != tt.want.Yql() {
+			t.Errorf("ydbValue(%s) = %s, want %s", tt.t.Yql(), got.Yql(), tt.want.Yql())
+		}
+	}
+
+	if _, err := ydbValue(types.TypeUint64, nil); err == nil {
+		t.Error("ydbValue() accepted NULL in a NOT NULL column")
+	}
+	if _, err := ydbValue(types.TypeInt8, cell("300")); err == nil {
+		t.Error("ydbValue() accepted 300 as Int8")
+	}
+}
+
+func TestUpToBulkUpsert(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_geo.sql": "-- +goose Up\n-- +goose bulkupsert table=geo file=geo.csv\nCREATE TABLE geo (id INTEGER);\n",
+		"geo.csv":     "id\n1\n2\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upserter := &fakeBulkUpserter{}
+	openBulkUpserter = func(*sql.DB) (BulkUpserter, error) { return upserter, nil }
+	t.Cleanup(func() { openBulkUpserter = openYDBBulkUpserter })
+
+	unlock := LockMigrations("")
+	defer unlock()
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpTo(ctx, db, store, migrations, 1); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := store.Version(ctx, db); err != nil || current != 1 {
+		t.Errorf("version = %d, %v, want 1", current, err)
+	}
+	if got := upserter.cells(); !reflect.DeepEqual(got, [][]string{{"1", "2"}}) {
+		t.Errorf("BulkUpsert() batches = %q, want the rows of geo.csv", got)
+	}
+}
+
+func TestUpToBulkUpsertResume(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_geo.sql": "-- +goose Up\n-- +goose bulkupsert table=geo file=geo.csv\nCREATE TABLE geo (id INTEGER);\n",
+		"geo.csv":     "id\n1\n2\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upserter := &fakeBulkUpserter{err: errors.New("deadline exceeded")}
+	openBulkUpserter = func(*sql.DB) (BulkUpserter, error) { return upserter, nil }
+	t.Cleanup(func() { openBulkUpserter = openYDBBulkUpserter })
+
+	unlock := LockMigrations("")
+	defer unlock()
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = UpTo(ctx, db, store, migrations, 1)
+	if err == nil || !strings.Contains(err.Error(), "Up statements are committed") {
+		t.Fatalf("UpTo() error = %v, want the committed Up statements named", err)
+	}
+	if current, applied, err := store.AppliedVersions(ctx, db); err != nil || current != 0 || len(applied) != 0 {
+		t.Fatalf("AppliedVersions() after the failed load = %d, %v, %v, want nothing applied", current, applied, err)
+	}
+
+	// The retry doesn't create the table again.
+	upserter.err = nil
+	if _, err := UpTo(ctx, db, store, migrations, 1); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := store.Version(ctx, db); err != nil || current != 1 {
+		t.Errorf("version = %d, %v, want 1", current, err)
+	}
+	if pending, err := store.bulkLoadPending(ctx, db, 1); err != nil || pending {
+		t.Errorf("bulkLoadPending() = %v, %v after the load, want false", pending, err)
+	}
+	if got := upserter.cells(); !reflect.DeepEqual(got, [][]string{{"1", "2"}}) {
+		t.Errorf("BulkUpsert() batches = %q, want the rows of geo.csv", got)
+	}
+}
@@ -4,3 +4,6 @@ import "time"
 
 const DefaultTimeout = 1 * time.Minute
 const DefaultEndpoint = "api.cloud.yandex.net:443"
+
+// SlowMigrationThreshold is the duration after which a migration is reported as slow.
+const SlowMigrationThreshold = 30 * time.Second
@@ -0,0 +1,143 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
+)
+
+// DestructiveOKAnnotation marks a migration file whose destructive statements have been reviewed.
+const DestructiveOKAnnotation = "destructive-ok"
+
+var (
+	lineComment         = regexp.MustCompile(`--[^\n]*`)
+	destructiveKeywords = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)^\s*DROP\s+`),
+		regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+.*\bDROP\s+`),
+		regexp.MustCompile(`(?is)^\s*DELETE\s+FROM\s+`),
+	}
+)
+
+// IsDestructive reports whether the statement drops a scheme object, a column or an index,
+// or deletes rows.
+func IsDestructive(statement string) bool {
+	statement = lineComment.ReplaceAllString(statement, "")
+	for _, re := range destructiveKeywords {
+		if re.MatchString(statement) {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingMigrations returns the migrations run when migrating from current to version in the order
+// they run, and whether they are applied up or rolled back.
+func PendingMigrations(migrations goose.Migrations, current int64, version int64) (goose.Migrations, bool) {
+	var pending goose.Migrations
+	if version >= current {
+		for _, migration := range migrations {
+			if migration.Version > current && migration.Version <= version {
+				pending = append(pending, migration)
+			}
+		}
+		return pending, true
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version <= current && migrations[i].Version > version {
+			pending = append(pending, migrations[i])
+		}
+	}
+	return pending, false
+}
+
+// DestructiveStatements returns a description of every destructive statement run by the pending
+// migrations which are not annotated with DestructiveOKAnnotation.
+func DestructiveStatements(pending goose.Migrations, up bool) ([]string, error) {
+	var found []string
+	for _, migration := range pending {
+		if filepath.Ext(migration.Source) != ".sql" {
+			continue
+		}
+		parsed, err := ParseSQLMigrationFile(migration.Source)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.HasAnnotation(DestructiveOKAnnotation) {
+			continue
+		}
+		for _, stmt := range parsed.Statements(up) {
+			if IsDestructive(stmt.SQL) {
+				found = append(found, fmt.Sprintf("%s:%d: %s", migration.Source, stmt.Line, firstLine(stmt.SQL)))
+			}
+		}
+	}
+	return found, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
+
+// RedoDestructiveStatements returns a description of every destructive statement run by redoing the
+// migrations: their Down statements in reverse order, then their Up statements.
+func RedoDestructiveStatements(redo goose.Migrations) ([]string, error) {
+	reversed := make(goose.Migrations, 0, len(redo))
+	for i := len(redo) - 1; i >= 0; i-- {
+		reversed = append(reversed, redo[i])
+	}
+	down, err := DestructiveStatements(reversed, false)
+	if err != nil {
+		return nil, err
+	}
+	up, err := DestructiveStatements(redo, true)
+	if err != nil {
+		return nil, err
+	}
+	return append(down, up...), nil
+}
+
+// checkDestructive fails the plan if the migrations run from current to version contain destructive
+// statements and the resource does not set allow_destructive.
+func checkDestructive(ctx context.Context, plan attributeGetter, migrations goose.Migrations, current int64, version int64) diag.Diagnostics {
+	pending, up := PendingMigrations(migrations, current, version)
+	return checkDestructiveStatements(ctx, plan, "The planned migrations", func() ([]string, error) {
+		return DestructiveStatements(pending, up)
+	})
+}
+
+// checkDestructiveStatements fails the plan if find returns destructive statements and the resource
+// does not set allow_destructive. what names the statements in the error.
+func checkDestructiveStatements(ctx context.Context, plan attributeGetter, what string, find func() ([]string, error)) diag.Diagnostics {
+	var allowDestructive types.Bool
+
+	diags := plan.GetAttribute(ctx, path.Root("allow_destructive"), &allowDestructive)
+	if diags.HasError() || allowDestructive.ValueBool() {
+		return diags
+	}
+
+	found, err := find()
+	if err != nil {
+		diags.AddError("Failed to parse migrations", err.Error())
+		return diags
+	}
+	if len(found) > 0 {
+		diags.AddAttributeError(
+			path.Root("allow_destructive"),
+			"Destructive migration statements",
+			fmt.Sprintf("%s contain destructive statements:\n%s\n\n"+
+				"Set allow_destructive = true or annotate reviewed files with `-- +goose %s`.",
+				what, strings.Join(found, "\n"), DestructiveOKAnnotation),
+		)
+	}
+	return diags
+}
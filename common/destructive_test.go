@@ -0,0 +1,95 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsDestructive(t *testing.T) {
+	tests := map[string]bool{
+		"DROP TABLE orders;":                                 true,
+		"drop index orders_idx on orders;":                   true,
+		"ALTER TABLE orders DROP COLUMN amount;":             true,
+		"ALTER TABLE `orders`\n  DROP INDEX by_customer;":    true,
+		"DELETE FROM orders WHERE id = 1;":                   true,
+		"-- cleanup\nDROP TABLE orders;":                     true,
+		"CREATE TABLE orders (id Uint64, PRIMARY KEY (id));": false,
+		"ALTER TABLE orders ADD COLUMN amount Uint64;":       false,
+		"UPSERT INTO orders (id) VALUES (1); -- DROP later":  false,
+		"SELECT * FROM dropped_orders;":                      false,
+	}
+	for statement, want := range tests {
+		if got := IsDestructive(statement); got != want {
+			t.Errorf("IsDestructive(%q) = %v, want %v", statement, got, want)
+		}
+	}
+}
+
+func TestDestructiveStatements(t *testing.T) {
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_cleanup.sql":  "-- +goose Up\nALTER TABLE orders DROP COLUMN amount;\n-- +goose Down\nALTER TABLE orders ADD COLUMN amount Uint64;\n",
+		"003_reviewed.sql": "-- +goose Up\n-- +goose destructive-ok\nDROP TABLE legacy;\n-- +goose Down\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		current int64
+		version int64
+		want    []string
+	}{
+		{name: "up", current: 0, version: 3, want: []string{"002_cleanup.sql:2"}},
+		{name: "down", current: 3, version: 0, want: []string{"001_orders.sql:4"}},
+		{name: "nothing pending", current: 3, version: 3, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, up := PendingMigrations(migrations, tt.current, tt.version)
+			found, err := DestructiveStatements(pending, up)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != len(tt.want) {
+				t.Fatalf("DestructiveStatements() = %v, want %v", found, tt.want)
+			}
+			for i := range tt.want {
+				if !strings.Contains(found[i], tt.want[i]) {
+					t.Errorf("DestructiveStatements()[%d] = %q, want %q", i, found[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRedoDestructiveStatements(t *testing.T) {
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":  "-- +goose Up\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_cleanup.sql": "-- +goose Up\nALTER TABLE orders DROP COLUMN amount;\n-- +goose Down\nALTER TABLE orders ADD COLUMN amount Uint64;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redo, err := RedoMigrations(migrations, []int64{1, 2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := RedoDestructiveStatements(redo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"001_orders.sql:4", "002_cleanup.sql:2"}
+	if len(found) != len(want) {
+		t.Fatalf("RedoDestructiveStatements() = %v, want %v", found, want)
+	}
+	for i := range want {
+		if !strings.Contains(found[i], want[i]) {
+			t.Errorf("RedoDestructiveStatements()[%d] = %q, want %q", i, found[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/pressly/goose/v3"
+)
+
+// DriftedMigrations returns the applied migrations whose file no longer matches the checksum
+// recorded in the history when they were applied.
+func DriftedMigrations(history []HistoryEntry, migrations goose.Migrations) ([]string, error) {
+	var drifted []string
+	for _, entry := range history {
+		if entry.Checksum == "" {
+			continue
+		}
+		migration, err := migrations.Current(entry.Version)
+		if err != nil {
+			continue
+		}
+		checksum, err := FileChecksum(migration.Source)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != entry.Checksum {
+			drifted = append(drifted, fmt.Sprintf("%s (applied as %s by %s)", migration.Source, entry.FileName, entry.AppliedBy))
+		}
+	}
+	return drifted, nil
+}
+
+// checkDrift warns about applied migrations which were edited after they had been applied.
+// Editing them has no effect on the database: the change has to be a new migration.
+func checkDrift(ctx context.Context, state attributeGetter, migrations goose.Migrations) diag.Diagnostics {
+	var history []HistoryEntry
+	diags := state.GetAttribute(ctx, path.Root("history"), &history)
+	if diags.HasError() || len(history) == 0 {
+		return nil
+	}
+
+	drifted, err := DriftedMigrations(history, migrations)
+	if err != nil {
+		diags.AddWarning("Failed to check applied migrations", err.Error())
+		return diags
+	}
+	if len(drifted) > 0 {
+		diags.AddAttributeWarning(
+			path.Root("history"),
+			"Applied migrations changed",
+			fmt.Sprintf("These migrations differ from the files recorded in the version table when they were applied:\n%s\n\n"+
+				"The changes are not applied to the database. Revert them and add a new migration instead.",
+				strings.Join(drifted, "\n")),
+		)
+	}
+	return diags
+}
+
+// VersionGaps compares the versions applied in the database with the migration files.
+// missing are the files below the current version which aren't applied: migrating up skips them.
+// unknown are the applied versions without a file.
+func VersionGaps(migrations goose.Migrations, current int64, applied []int64) (missing []string, unknown []int64) {
+	isApplied := make(map[int64]bool, len(applied))
+	for _, version := range applied {
+		isApplied[version] = true
+	}
+	for _, migration := range migrations {
+		if migration.Version < current && !isApplied[migration.Version] {
+			missing = append(missing, migration.Source)
+		}
+	}
+	for _, version := range applied {
+		if _, err := migrations.Current(version); err != nil {
+			unknown = append(unknown, version)
+		}
+	}
+	return missing, unknown
+}
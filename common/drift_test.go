@@ -0,0 +1,30 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+func TestVersionGaps(t *testing.T) {
+	migrations := goose.Migrations{
+		{Version: 1, Source: "001_orders.sql"},
+		{Version: 2, Source: "002_payments.sql"},
+		{Version: 3, Source: "003_refunds.sql"},
+		{Version: 4, Source: "004_users.sql"},
+	}
+
+	missing, unknown := VersionGaps(migrations, 5, []int64{1, 3, 5})
+	if want := []string{"002_payments.sql", "004_users.sql"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+	if want := []int64{5}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown = %v, want %v", unknown, want)
+	}
+
+	missing, unknown = VersionGaps(migrations, 2, []int64{1, 2})
+	if missing != nil || unknown != nil {
+		t.Errorf("VersionGaps() = %v, %v, want no gaps", missing, unknown)
+	}
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3/database"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// StatementMode returns the YDB query mode the statement is executed in.
+func StatementMode(statement string) ydb.QueryMode {
+	if IsSchemeStatement(statement) {
+		return ydb.SchemeQueryMode
+	}
+	return ydb.DataQueryMode
+}
+
+func withSchemeMode(ctx context.Context) context.Context {
+	return ydb.WithQueryMode(ctx, ydb.SchemeQueryMode)
+}
+
+// Batch is a group of statements executed together: a scheme statement on its own,
+// or consecutive data statements in one transaction.
+type Batch struct {
+	Statements []Statement
+	Scheme     bool
+	Tx         bool
+}
+
+// Lines describes the lines of the migration file the batch runs, e.g. "lines 3-7".
+func (b Batch) Lines() string {
+	first, last := b.Statements[0].Line, b.Statements[len(b.Statements)-1].Line
+	if first == last {
+		return fmt.Sprintf("line %d", first)
+	}
+	return fmt.Sprintf("lines %d-%d", first, last)
+}
+
+// PlanBatches splits the statements into the batches they are executed in, in order.
+// YDB can't change the scheme in a transaction, so every scheme statement is a batch of its own.
+// With useTx, the data statements between them share a transaction; without it, every statement runs on its own.
+func PlanBatches(statements []Statement, useTx bool) []Batch {
+	var batches []Batch
+	for _, statement := range SplitStatements(statements) {
+		scheme := IsSchemeStatement(statement.SQL)
+		if useTx && !scheme && len(batches) > 0 && batches[len(batches)-1].Tx {
+			last := &batches[len(batches)-1]
+			last.Statements = append(last.Statements, statement)
+			continue
+		}
+		batches = append(batches, Batch{
+			Statements: []Statement{statement},
+			Scheme:     scheme,
+			Tx:         useTx && !scheme,
+		})
+	}
+	return batches
+}
+
+// runStatements executes the statements batch by batch and then runs record, if any.
+// A statement adding an index returns once the index is built.
+// record joins the transaction of the last batch if it has one, otherwise it runs in a transaction of its own.
+// If a batch fails, the error tells which of the statements have been committed already.
+func runStatements(
+	ctx context.Context,
+	db *sql.DB,
+	statements []Statement,
+	useTx bool,
+	record func(ctx context.Context, db database.DBTxConn) error,
+) error {
+	batches := PlanBatches(statements, useTx)
+	recorded := record == nil
+	for i, batch := range batches {
+		var err error
+		if batch.Tx {
+			last := i == len(batches)-1
+			tflog.Info(ctx, fmt.Sprintf("goose: running %s in a transaction", batch.Lines()))
+			err = inTx(ctx, db, func(tx *sql.Tx) error {
+				if err := execStatements(ctx, tx, batch.Statements); err != nil {
+					return err
+				}
+				if last && record != nil {
+					return record(ctx, tx)
+				}
+				return nil
+			})
+			recorded = recorded || last
+		} else {
+			mode := "data query"
+			if batch.Scheme {
+				mode = "scheme query"
+			}
+			tflog.Info(ctx, fmt.Sprintf("goose: running %s as a %s", batch.Lines(), mode))
+			err = execStatements(ctx, db, batch.Statements)
+			if err == nil && batch.Scheme {
+				statement := batch.Statements[0]
+				if waitErr := waitIndexBuilds(ctx, db, statement.SQL); waitErr != nil {
+					err = fmt.Errorf("line %d: %w", statement.Line, waitErr)
+				}
+			}
+		}
+		if err != nil {
+			if i > 0 {
+				return fmt.Errorf("%w (%s committed before the failure)", err, committedLines(batches[:i]))
+			}
+			return err
+		}
+	}
+
+	if recorded {
+		return nil
+	}
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		return record(ctx, tx)
+	})
+}
+
+func committedLines(batches []Batch) string {
+	last := batches[len(batches)-1]
+	return Batch{Statements: []Statement{batches[0].Statements[0], last.Statements[len(last.Statements)-1]}}.Lines()
+}
+
+func execStatements(ctx context.Context, db database.DBTxConn, statements []Statement) error {
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ydb.WithQueryMode(ctx, StatementMode(statement.SQL)), statement.SQL); err != nil {
+			return newStatementError(statement, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+func TestStatementMode(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      ydb.QueryMode
+	}{
+		{statement: "CREATE TABLE orders (id Uint64, PRIMARY KEY (id));", want: ydb.SchemeQueryMode},
+		{statement: "-- orders\nALTER TABLE orders ADD COLUMN status Utf8;", want: ydb.SchemeQueryMode},
+		{statement: "drop table orders;", want: ydb.SchemeQueryMode},
+		{statement: "UPSERT INTO orders (id) VALUES (1);", want: ydb.DataQueryMode},
+		{statement: "SELECT * FROM orders;", want: ydb.DataQueryMode},
+		{statement: "DELETE FROM orders WHERE id = 1;", want: ydb.DataQueryMode},
+	}
+	for _, tt := range tests {
+		if got := StatementMode(tt.statement); got != tt.want {
+			t.Errorf("StatementMode(%q) = %v, want %v", tt.statement, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatches(t *testing.T) {
+	statements := []Statement{
+		{SQL: "CREATE TABLE orders (id Uint64, PRIMARY KEY (id));", Line: 2},
+		{SQL: "UPSERT INTO orders (id) VALUES (1);", Line: 3},
+		{SQL: "UPSERT INTO orders (id) VALUES (2);", Line: 4},
+		{SQL: "ALTER TABLE orders ADD COLUMN status Utf8;", Line: 5},
+		{SQL: "UPDATE orders SET status = 'new';", Line: 6},
+	}
+
+	var got []string
+	for _, batch := range PlanBatches(statements, true) {
+		got = append(got, fmt.Sprintf("%s scheme=%t tx=%t", batch.Lines(), batch.Scheme, batch.Tx))
+	}
+	want := []string{
+		"line 2 scheme=true tx=false",
+		"lines 3-4 scheme=false tx=true",
+		"line 5 scheme=true tx=false",
+		"line 6 scheme=false tx=true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanBatches() = %q, want %q", got, want)
+	}
+
+	if batches := PlanBatches(statements, false); len(batches) != len(statements) || batches[1].Tx {
+		t.Errorf("PlanBatches() without a transaction = %+v, want every statement on its own", batches)
+	}
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
+)
+
+type fleetVersionsPlanModifier struct{}
+
+func (m fleetVersionsPlanModifier) Description(_ context.Context) string {
+	return "Calculates the version of every database of the fleet"
+}
+
+func (m fleetVersionsPlanModifier) MarkdownDescription(_ context.Context) string {
+	return "Calculates the version of every database of the fleet"
+}
+
+// PlanModifyMap keeps the version of every database which is at the target version and plans the others as unknown:
+// a database which fails to migrate keeps the version it reached, so the result of apply is only known afterwards.
+func (m fleetVersionsPlanModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var databases types.Set
+	var dir types.String
+	var target *int64
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("databases"), &databases)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("migrations_dir"), &dir)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("target_version"), &target)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if databases.IsUnknown() || dir.IsUnknown() {
+		resp.PlanValue = types.MapUnknown(types.Int64Type)
+		return
+	}
+
+	migrations, err := CollectMigrations([]string{dir.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect migrations", err.Error())
+		return
+	}
+	version, diags := TargetVersion(migrations, target)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current := map[string]int64{}
+	if !req.StateValue.IsNull() && !req.StateValue.IsUnknown() {
+		resp.Diagnostics.Append(req.StateValue.ElementsAs(ctx, &current, false)...)
+	}
+	var names []string
+	resp.Diagnostics.Append(databases.ElementsAs(ctx, &names, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var up, down goose.Migrations
+	versions := make(map[string]attr.Value, len(names))
+	for _, name := range names {
+		from := current[name]
+		if from == version {
+			versions[name] = types.Int64Value(version)
+			continue
+		}
+		versions[name] = types.Int64Unknown()
+		// The databases furthest from the target run the migrations of all the others.
+		if pending, isUp := PendingMigrations(migrations, from, version); isUp && len(pending) > len(up) {
+			up = pending
+		} else if !isUp && len(pending) > len(down) {
+			down = pending
+		}
+	}
+
+	resp.Diagnostics.Append(checkDestructiveStatements(ctx, req.Plan, "The planned migrations", func() ([]string, error) {
+		found, err := DestructiveStatements(up, true)
+		if err != nil {
+			return nil, err
+		}
+		rollback, err := DestructiveStatements(down, false)
+		return append(found, rollback...), err
+	})...)
+	resp.Diagnostics.Append(checkIrreversibleMigrations(ctx, req.Plan, down, version)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	val, diag := types.MapValue(types.Int64Type, versions)
+	resp.Diagnostics.Append(diag...)
+	resp.PlanValue = val
+}
+
+func FleetVersionsPlanModifier() planmodifier.Map {
+	return fleetVersionsPlanModifier{}
+}
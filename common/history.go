@@ -0,0 +1,226 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3/database"
+)
+
+// RunID identifies the Terraform run in the version history.
+// Terraform starts the provider once per command, so it is generated once per process.
+var RunID = uuid.NewString()
+
+// HistoryEntry is an applied migration as recorded in the version table.
+type HistoryEntry struct {
+	Version    int64  `tfsdk:"version"`
+	FileName   string `tfsdk:"file_name"`
+	Checksum   string `tfsdk:"checksum"`
+	AppliedBy  string `tfsdk:"applied_by"`
+	DurationMs int64  `tfsdk:"duration_ms"`
+	RunID      string `tfsdk:"terraform_run_id"`
+}
+
+type historyColumn struct {
+	name    string
+	integer bool
+}
+
+var historyColumns = []historyColumn{
+	{name: "applied_by"},
+	{name: "checksum"},
+	{name: "duration_ms", integer: true},
+	{name: "file_name"},
+	{name: "terraform_run_id"},
+}
+
+// bulkLoadPendingColumn marks the record of a migration whose Up statements are committed, but whose
+// bulk loads haven't finished. It isn't part of the history.
+var bulkLoadPendingColumn = historyColumn{name: "bulk_load_pending", integer: true}
+
+// EnsureHistoryColumns adds the history columns missing in a version table created by goose.
+func (s *Store) EnsureHistoryColumns(ctx context.Context, db database.DBTxConn) error {
+	return s.addColumns(ctx, db)
+}
+
+func (s *Store) addColumns(ctx context.Context, db database.DBTxConn) error {
+	for _, column := range append(historyColumns, bulkLoadPendingColumn) {
+		if s.hasColumn(ctx, db, column.name) {
+			continue
+		}
+
+		tflog.Info(ctx, fmt.Sprintf("goose: adding column %s to %s", column.name, s.Tablename()))
+		q := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, s.Tablename(), column.name, s.columnType(column))
+		if _, err := db.ExecContext(withSchemeMode(ctx), q); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", column.name, s.Tablename(), err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) hasColumn(ctx context.Context, db database.DBTxConn, name string) bool {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT %s FROM %s LIMIT 1`, name, s.Tablename()))
+	if err != nil {
+		return false
+	}
+	return rows.Close() == nil
+}
+
+// Record stores the history columns of the applied migration.
+func (s *Store) Record(ctx context.Context, db database.DBTxConn, result MigrationResult) error {
+	checksum, err := FileChecksum(result.Source)
+	if err != nil {
+		return err
+	}
+	set := make([]string, 0, len(historyColumns))
+	for i, column := range historyColumns {
+		set = append(set, fmt.Sprintf("%s = %s", column.name, s.placeholder(i+1)))
+	}
+	q := fmt.Sprintf(`UPDATE %s SET %s WHERE version_id = %s`, s.Tablename(), strings.Join(set, ", "), s.placeholder(len(historyColumns)+1))
+	_, err = db.ExecContext(ctx, q,
+		s.AppliedBy,
+		checksum,
+		result.Duration.Milliseconds(),
+		filepath.Base(result.Source),
+		RunID,
+		result.Version,
+	)
+	return err
+}
+
+// markBulkLoadPending records that the Up statements of the migration are committed and its bulk loads
+// are about to run. The version is recorded as not applied, so the migration stays pending until
+// finishBulkLoad records it.
+func (s *Store) markBulkLoadPending(ctx context.Context, db database.DBTxConn, version int64) error {
+	q := fmt.Sprintf(`INSERT INTO %s (version_id, is_applied, %s) VALUES (%s, false, %s)`,
+		s.Tablename(), bulkLoadPendingColumn.name, s.placeholder(1), s.placeholder(2))
+	if s.dialect == database.DialectYdB {
+		q = fmt.Sprintf(`INSERT INTO %s (version_id, is_applied, tstamp, %s) VALUES (CAST($1 AS Uint64), false, CurrentUtcTimestamp(), $2)`,
+			s.Tablename(), bulkLoadPendingColumn.name)
+	}
+	if _, err := db.ExecContext(ctx, q, version, int64(1)); err != nil {
+		return fmt.Errorf("failed to mark the bulk load of version %d as pending: %w", version, err)
+	}
+	return nil
+}
+
+// bulkLoadPending reports whether an earlier run committed the Up statements of the migration
+// and failed to finish its bulk loads.
+func (s *Store) bulkLoadPending(ctx context.Context, db database.DBTxConn, version int64) (bool, error) {
+	var count int64
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE version_id = %s AND %s = 1`, s.Tablename(), s.placeholder(1), bulkLoadPendingColumn.name)
+	if err := db.QueryRowContext(ctx, q, version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to read the bulk load state of version %d: %w", version, err)
+	}
+	return count > 0, nil
+}
+
+// finishBulkLoad records the version marked by markBulkLoadPending as applied.
+func (s *Store) finishBulkLoad(ctx context.Context, db database.DBTxConn, version int64) error {
+	q := fmt.Sprintf(`UPDATE %s SET is_applied = true, %s = %s WHERE version_id = %s`,
+		s.Tablename(), bulkLoadPendingColumn.name, s.placeholder(1), s.placeholder(2))
+	if _, err := db.ExecContext(ctx, q, int64(0), version); err != nil {
+		return fmt.Errorf("failed to record version %d after its bulk loads: %w", version, err)
+	}
+	return nil
+}
+
+// HasVersionTable reports whether the version table exists.
+func (s *Store) HasVersionTable(ctx context.Context, db database.DBTxConn) bool {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT version_id FROM %s LIMIT 1`, s.Tablename()))
+	if err != nil {
+		return false
+	}
+	return rows.Close() == nil
+}
+
+// CountRecords returns the number of records in the version table.
+func (s *Store) CountRecords(ctx context.Context, db database.DBTxConn) (int64, error) {
+	var count int64
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, s.Tablename())).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count the records of %q: %w", s.Tablename(), err)
+	}
+	return count, nil
+}
+
+// CopyHistory copies the records of the version table of from, with their timestamps and history columns,
+// into the version table of the store. Records copied before are replaced, so a failed copy can be rerun.
+func (s *Store) CopyHistory(ctx context.Context, db database.DBTxConn, from *Store) error {
+	columns := []string{"version_id", "is_applied", "tstamp"}
+	for _, column := range historyColumns {
+		columns = append(columns, column.name)
+	}
+	verb := "UPSERT INTO"
+	if s.dialect != database.DialectYdB {
+		// Only the YDB version table is keyed by version_id; the others have an id of their own.
+		verb, columns = "INSERT OR REPLACE INTO", append([]string{"id"}, columns...)
+	}
+	list := strings.Join(columns, ", ")
+	q := fmt.Sprintf(`%s %s (%s) SELECT %s FROM %s`, verb, s.Tablename(), list, list, from.Tablename())
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("failed to copy version history from %q to %q: %w", from.Tablename(), s.Tablename(), err)
+	}
+	return nil
+}
+
+// ListHistory returns the applied migrations ordered by version.
+// A version table without the history columns, e.g. one only goose has written, has no history.
+func (s *Store) ListHistory(ctx context.Context, db database.DBTxConn) ([]HistoryEntry, error) {
+	for _, column := range historyColumns {
+		if !s.hasColumn(ctx, db, column.name) {
+			return nil, nil
+		}
+	}
+	q := fmt.Sprintf(
+		`SELECT version_id, applied_by, checksum, duration_ms, file_name, terraform_run_id FROM %s WHERE version_id > 0 AND is_applied = true ORDER BY version_id`,
+		s.Tablename(),
+	)
+	rows, err := db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var (
+			entry                                HistoryEntry
+			appliedBy, checksum, fileName, runID sql.NullString
+			duration                             sql.NullInt64
+		)
+		if err := rows.Scan(&entry.Version, &appliedBy, &checksum, &duration, &fileName, &runID); err != nil {
+			return nil, err
+		}
+		entry.AppliedBy = appliedBy.String
+		entry.Checksum = checksum.String
+		entry.DurationMs = duration.Int64
+		entry.FileName = fileName.String
+		entry.RunID = runID.String
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *Store) columnType(column historyColumn) string {
+	switch {
+	case s.dialect == database.DialectYdB && column.integer:
+		return "Int64"
+	case s.dialect == database.DialectYdB:
+		return "Utf8"
+	case column.integer:
+		return "INTEGER"
+	}
+	return "TEXT"
+}
+
+func (s *Store) placeholder(i int) string {
+	if s.dialect == database.DialectYdB {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
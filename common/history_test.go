@@ -0,0 +1,63 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_payments.sql": "-- +goose Up\nCREATE TABLE payments (id INTEGER);\n-- +goose Down\nDROP TABLE payments;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock := LockMigrations("")
+	defer unlock()
+
+	history, err := NewStore(database.DialectSQLite3, "", "serviceAccount:ajesa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpTo(ctx, db, history, migrations, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := history.ListHistory(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ListHistory() = %+v, want 2 entries", entries)
+	}
+	checksum, err := FileChecksum(migrations[1].Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HistoryEntry{Version: 2, FileName: "002_payments.sql", Checksum: checksum, AppliedBy: "serviceAccount:ajesa", RunID: RunID}
+	entries[1].DurationMs = 0
+	if entries[1] != want {
+		t.Errorf("ListHistory()[1] = %+v, want %+v", entries[1], want)
+	}
+
+	drifted, err := DriftedMigrations(entries, migrations)
+	if err != nil || len(drifted) != 0 {
+		t.Fatalf("DriftedMigrations() = %v, %v, want none", drifted, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "002_payments.sql"), []byte("-- +goose Up\nCREATE TABLE payments (id TEXT);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	drifted, err = DriftedMigrations(entries, migrations)
+	if err != nil || len(drifted) != 1 {
+		t.Errorf("DriftedMigrations() = %v, %v, want 002_payments.sql", drifted, err)
+	}
+}
@@ -0,0 +1,224 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/ydb-platform/ydb-go-genproto/Ydb_Operation_V1"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Issue"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Operations"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Table"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+var (
+	alterTableStatement = regexp.MustCompile("(?is)^ALTER\\s+TABLE\\s+(`[^`]+`|\\S+)\\s")
+	addIndexClause      = regexp.MustCompile("(?is)\\bADD\\s+INDEX\\s+(`[^`]+`|\\w+)")
+)
+
+// indexBuildPollInterval is how often the state of an index build is checked.
+var indexBuildPollInterval = 5 * time.Second
+
+// IndexBuild is the state of an operation building a secondary index.
+type IndexBuild struct {
+	ID string
+	// Table is the full path of the table the index is built for.
+	Table string
+	Index string
+	Ready bool
+	// Progress is the share of the build done, in percent.
+	Progress float32
+	// Err is the reason a ready build failed.
+	Err error
+}
+
+// IndexBuilds lists and tracks the index build operations of a database.
+type IndexBuilds interface {
+	List(ctx context.Context) ([]IndexBuild, error)
+	Get(ctx context.Context, id string) (IndexBuild, error)
+}
+
+var openIndexBuilds = openYDBIndexBuilds
+
+func openYDBIndexBuilds(db *sql.DB) (IndexBuilds, error) {
+	driver, err := ydb.Unwrap(db)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for index builds requires a YDB database: %w", err)
+	}
+	return &ydbIndexBuilds{client: Ydb_Operation_V1.NewOperationServiceClient(ydb.GRPCConn(driver))}, nil
+}
+
+// AddedIndexes returns the table and the names of the indexes a statement like
+// `ALTER TABLE orders ADD INDEX by_status GLOBAL ON (status)` builds. YDB builds them in the background
+// after the statement returns.
+func AddedIndexes(statement string) (table string, indexes []string) {
+	statement = skipPragmas(statement)
+	match := alterTableStatement.FindStringSubmatch(statement)
+	if match == nil {
+		return "", nil
+	}
+	for _, index := range addIndexClause.FindAllStringSubmatch(statement, -1) {
+		indexes = append(indexes, strings.Trim(index[1], "`"))
+	}
+	if len(indexes) == 0 {
+		return "", nil
+	}
+	return strings.Trim(match[1], "`"), indexes
+}
+
+// waitIndexBuilds waits for the indexes added by the statement to be built, logging the progress.
+// If ctx is done first, e.g. the resource timeout expires, the error names the operation to cancel.
+func waitIndexBuilds(ctx context.Context, db *sql.DB, statement string) error {
+	table, indexes := AddedIndexes(statement)
+	if len(indexes) == 0 {
+		return nil
+	}
+	builds, err := openIndexBuilds(db)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if err := waitIndexBuild(ctx, builds, table, index); err != nil {
+			return fmt.Errorf("index %s of %s: %w", index, table, err)
+		}
+	}
+	return nil
+}
+
+func waitIndexBuild(ctx context.Context, builds IndexBuilds, table, index string) error {
+	all, err := builds.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list index builds: %w", err)
+	}
+	var build *IndexBuild
+	for i := range all {
+		if !all[i].Ready && all[i].Index == index && sameTable(all[i].Table, table) {
+			build = &all[i]
+			break
+		}
+	}
+	if build == nil {
+		// The build has finished before it could be listed.
+		return nil
+	}
+
+	start := time.Now()
+	for {
+		tflog.Info(ctx, fmt.Sprintf("goose: building index %s of %s: %.1f%%", index, build.Table, build.Progress), map[string]interface{}{
+			"operation_id": build.ID,
+			"duration_ms":  time.Since(start).Milliseconds(),
+		})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"still building after %s, cancel operation %s with `ydb operation cancel %s` before retrying: %w",
+				time.Since(start).Round(time.Second), build.ID, build.ID, ctx.Err(),
+			)
+		case <-time.After(indexBuildPollInterval):
+		}
+
+		next, err := builds.Get(ctx, build.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get index build operation %s: %w", build.ID, err)
+		}
+		if next.Ready {
+			if next.Err != nil {
+				return fmt.Errorf("operation %s failed: %w", build.ID, next.Err)
+			}
+			tflog.Info(ctx, fmt.Sprintf("goose: built index %s of %s", index, build.Table), map[string]interface{}{
+				"operation_id": build.ID,
+				"duration_ms":  time.Since(start).Milliseconds(),
+			})
+			return nil
+		}
+		build.Progress = next.Progress
+	}
+}
+
+// sameTable reports whether the full path of a table is the table named in a statement,
+// which may be relative to the database or to a TablePathPrefix.
+func sameTable(fullPath, name string) bool {
+	if strings.HasPrefix(name, "/") {
+		return fullPath == name
+	}
+	return strings.HasSuffix(fullPath, "/"+name)
+}
+
+type ydbIndexBuilds struct {
+	client Ydb_Operation_V1.OperationServiceClient
+}
+
+func (b *ydbIndexBuilds) List(ctx context.Context) ([]IndexBuild, error) {
+	var (
+		builds []IndexBuild
+		token  string
+	)
+	for {
+		resp, err := b.client.ListOperations(ctx, &Ydb_Operations.ListOperationsRequest{Kind: "buildindex", PageToken: token})
+		if err != nil {
+			return nil, err
+		}
+		if resp.GetStatus() != Ydb.StatusIds_SUCCESS {
+			return nil, statusError(resp.GetStatus(), resp.GetIssues())
+		}
+		for _, operation := range resp.GetOperations() {
+			build, err := indexBuild(operation)
+			if err != nil {
+				return nil, err
+			}
+			builds = append(builds, build)
+		}
+		if token = resp.GetNextPageToken(); token == "" {
+			return builds, nil
+		}
+	}
+}
+
+func (b *ydbIndexBuilds) Get(ctx context.Context, id string) (IndexBuild, error) {
+	resp, err := b.client.GetOperation(ctx, &Ydb_Operations.GetOperationRequest{Id: id})
+	if err != nil {
+		return IndexBuild{}, err
+	}
+	return indexBuild(resp.GetOperation())
+}
+
+func indexBuild(operation *Ydb_Operations.Operation) (IndexBuild, error) {
+	build := IndexBuild{ID: operation.GetId(), Ready: operation.GetReady()}
+	if operation.GetMetadata() != nil {
+		var metadata Ydb_Table.IndexBuildMetadata
+		if err := operation.GetMetadata().UnmarshalTo(&metadata); err != nil {
+			return build, fmt.Errorf("operation %s: %w", build.ID, err)
+		}
+		build.Table = metadata.GetDescription().GetPath()
+		build.Index = metadata.GetDescription().GetIndex().GetName()
+		build.Progress = metadata.GetProgress()
+	}
+	if build.Ready && operation.GetStatus() != Ydb.StatusIds_SUCCESS {
+		build.Err = statusError(operation.GetStatus(), operation.GetIssues())
+	}
+	return build, nil
+}
+
+func statusError(status Ydb.StatusIds_StatusCode, issues []*Ydb_Issue.IssueMessage) error {
+	var messages []string
+	var collect func(issues []*Ydb_Issue.IssueMessage)
+	collect = func(issues []*Ydb_Issue.IssueMessage) {
+		for _, issue := range issues {
+			if issue.GetMessage() != "" {
+				messages = append(messages, issue.GetMessage())
+			}
+			collect(issue.GetIssues())
+		}
+	}
+	collect(issues)
+	if len(messages) == 0 {
+		return fmt.Errorf("status %s", status)
+	}
+	return fmt.Errorf("status %s: %s", status, strings.Join(messages, "; "))
+}
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddedIndexes(t *testing.T) {
+	tests := []struct {
+		statement string
+		table     string
+		indexes   []string
+	}{
+		{
+			statement: "ALTER TABLE orders ADD INDEX by_status GLOBAL ON (status);",
+			table:     "orders",
+			indexes:   []string{"by_status"},
+		},
+		{
+			statement: "PRAGMA TablePathPrefix = '/local/shop';\n-- lookups\nalter table `orders` add index `by_user` global on (user_id), add index by_date global async on (created_at);",
+			table:     "orders",
+			indexes:   []string{"by_user", "by_date"},
+		},
+		{statement: "ALTER TABLE orders ADD COLUMN status Utf8;"},
+		{statement: "ALTER TABLE orders DROP INDEX by_status;"},
+		{statement: "CREATE TABLE orders (id Uint64, status Utf8, PRIMARY KEY (id), INDEX by_status GLOBAL ON (status));"},
+		{statement: "UPSERT INTO orders (id, status) VALUES (1, 'ADD INDEX x');"},
+	}
+	for _, tt := range tests {
+		table, indexes := AddedIndexes(tt.statement)
+		if table != tt.table || !reflect.DeepEqual(indexes, tt.indexes) {
+			t.Errorf("AddedIndexes(%q) = %q, %q, want %q, %q", tt.statement, table, indexes, tt.table, tt.indexes)
+		}
+	}
+}
+
+type fakeIndexBuilds struct {
+	builds []IndexBuild
+	// states are returned by Get one by one, the last one repeatedly.
+	states []IndexBuild
+}
+
+func (f *fakeIndexBuilds) List(context.Context) ([]IndexBuild, error) {
+	return f.builds, nil
+}
+
+func (f *fakeIndexBuilds) Get(_ context.Context, id string) (IndexBuild, error) {
+	state := f.states[0]
+	if len(f.states) > 1 {
+		f.states = f.states[1:]
+	}
+	state.ID = id
+	return state, nil
+}
+
+func TestWaitIndexBuild(t *testing.T) {
+	defer func(interval time.Duration) { indexBuildPollInterval = interval }(indexBuildPollInterval)
+	indexBuildPollInterval = time.Millisecond
+
+	building := []IndexBuild{
+		{ID: "ydb://buildindex/7?id=1", Table: "/local/orders", Index: "by_status", Ready: true},
+		{ID: "ydb://buildindex/7?id=2", Table: "/local/users", Index: "by_status"},
+		{ID: "ydb://buildindex/7?id=3", Table: "/local/orders", Index: "by_status", Progress: 10},
+	}
+
+	t.Run("done", func(t *testing.T) {
+		builds := &fakeIndexBuilds{builds: building, states: []IndexBuild{{Progress: 50}, {Ready: true}}}
+		if err := waitIndexBuild(context.Background(), builds, "orders", "by_status"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("finished before listing", func(t *testing.T) {
+		builds := &fakeIndexBuilds{builds: building[:2]}
+		if err := waitIndexBuild(context.Background(), builds, "orders", "by_status"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		builds := &fakeIndexBuilds{builds: building, states: []IndexBuild{{Ready: true, Err: errors.New("status BAD_REQUEST")}}}
+		err := waitIndexBuild(context.Background(), builds, "/local/orders", "by_status")
+		if err == nil || !strings.Contains(err.Error(), "BAD_REQUEST") {
+			t.Fatalf("got %v, want the build failure", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		builds := &fakeIndexBuilds{builds: building, states: []IndexBuild{{Progress: 50}}}
+		err := waitIndexBuild(ctx, builds, "orders", "by_status")
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "ydb operation cancel ydb://buildindex/7?id=3") {
+			t.Fatalf("got %v, want a timeout naming the operation", err)
+		}
+	})
+}
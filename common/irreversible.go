@@ -0,0 +1,77 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
+)
+
+// IsIrreversible reports whether rolling the migration back runs nothing.
+func IsIrreversible(migration *goose.Migration) (bool, error) {
+	if filepath.Ext(migration.Source) != ".sql" {
+		return migration.DownFnContext == nil && migration.DownFnNoTxContext == nil, nil
+	}
+	parsed, err := ParseSQLMigrationFile(migration.Source)
+	if err != nil {
+		return false, err
+	}
+	return len(parsed.Down) == 0, nil
+}
+
+// IrreversibleMigrations returns the sources of the migrations which have nothing to roll back.
+func IrreversibleMigrations(migrations goose.Migrations) ([]string, error) {
+	var found []string
+	for _, migration := range migrations {
+		irreversible, err := IsIrreversible(migration)
+		if err != nil {
+			return nil, err
+		}
+		if irreversible {
+			found = append(found, migration.Source)
+		}
+	}
+	return found, nil
+}
+
+// checkIrreversible fails the plan if rolling back from current to version passes a migration without Down
+// statements and the resource does not set allow_irreversible_skip.
+func checkIrreversible(ctx context.Context, plan attributeGetter, migrations goose.Migrations, current int64, version int64) diag.Diagnostics {
+	pending, up := PendingMigrations(migrations, current, version)
+	if up {
+		return nil
+	}
+	return checkIrreversibleMigrations(ctx, plan, pending, version)
+}
+
+// checkIrreversibleMigrations fails the plan if the migrations rolled back to reach version include
+// one without Down statements and the resource does not set allow_irreversible_skip.
+func checkIrreversibleMigrations(ctx context.Context, plan attributeGetter, rollback goose.Migrations, version int64) diag.Diagnostics {
+	var allowSkip types.Bool
+
+	diags := plan.GetAttribute(ctx, path.Root("allow_irreversible_skip"), &allowSkip)
+	if diags.HasError() || allowSkip.ValueBool() || len(rollback) == 0 {
+		return diags
+	}
+
+	found, err := IrreversibleMigrations(rollback)
+	if err != nil {
+		diags.AddError("Failed to parse migrations", err.Error())
+		return diags
+	}
+	if len(found) > 0 {
+		diags.AddAttributeError(
+			path.Root("target_version"),
+			"Irreversible migrations",
+			fmt.Sprintf("Rolling back to version %d requires rolling back migrations without Down statements:\n%s\n\n"+
+				"Set allow_irreversible_skip = true to record them as rolled back without running anything.",
+				version, strings.Join(found, "\n")),
+		)
+	}
+	return diags
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestIrreversibleMigrations(t *testing.T) {
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_backfill.sql": "-- +goose Up\nUPDATE orders SET amount = 0;\n",
+		"003_comment.sql":  "-- +goose Up\nALTER TABLE orders ADD COLUMN note Utf8;\n-- +goose Down\n-- nothing to do\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, up := PendingMigrations(migrations, 3, 0)
+	if up {
+		t.Fatal("PendingMigrations() planned an up migration")
+	}
+	found, err := IrreversibleMigrations(pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{migrations[2].Source, migrations[1].Source}
+	if len(found) != len(want) {
+		t.Fatalf("IrreversibleMigrations() = %v, want %v", found, want)
+	}
+	for i := range want {
+		if found[i] != want[i] {
+			t.Errorf("IrreversibleMigrations()[%d] = %s, want %s", i, found[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/pressly/goose/v3"
+)
+
+const DefaultMigrationTable = "goose_db_version"
+
+var migrationLock sync.Mutex
+
+// LockMigrations takes the migration lock and points goose to the given version table.
+// goose keeps the version table name in a package variable, so the lock has to be held
+// while migrations are read or applied. The returned function releases the lock.
+func LockMigrations(table string) func() {
+	migrationLock.Lock()
+	if table == "" {
+		table = DefaultMigrationTable
+	}
+	goose.SetTableName(table)
+	return migrationLock.Unlock
+}
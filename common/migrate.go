@@ -0,0 +1,286 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+// MigrationResult describes a single applied or rolled back migration.
+type MigrationResult struct {
+	Version    int64
+	Source     string
+	Up         bool
+	Duration   time.Duration
+	Statements int
+}
+
+// Direction returns "up" or "down".
+func (r MigrationResult) Direction() string {
+	if r.Up {
+		return "up"
+	}
+	return "down"
+}
+
+// ApplyMigration runs the migration in the given direction, records its version in the store
+// and logs how long it took. Rolling back a migration without Down statements fails unless allowIrreversible is set,
+// in which case it is recorded as rolled back without running anything.
+func ApplyMigration(ctx context.Context, db *sql.DB, store *Store, migration *goose.Migration, up bool, allowIrreversible bool) (MigrationResult, error) {
+	result := MigrationResult{
+		Version: migration.Version,
+		Source:  migration.Source,
+		Up:      up,
+	}
+	var parsed *SQLMigration
+	if filepath.Ext(migration.Source) == ".sql" {
+		var err error
+		parsed, err = ParseSQLMigrationFile(migration.Source)
+		if err != nil {
+			return result, err
+		}
+		result.Statements = len(parsed.Statements(up))
+	}
+
+	if !up {
+		irreversible, err := IsIrreversible(migration)
+		if err != nil {
+			return result, err
+		}
+		if irreversible && !allowIrreversible {
+			return result, fmt.Errorf("%s has no Down statements, allow irreversible skips to record it as rolled back "+
+				"without running anything", filepath.Base(migration.Source))
+		}
+		if irreversible {
+			tflog.Warn(ctx, fmt.Sprintf("goose: %s has no Down statements, recording it as rolled back", migration.Source))
+		}
+	}
+
+	start := time.Now()
+	var err error
+	switch {
+	case parsed != nil:
+		err = runSQLMigration(ctx, db, store, migration, parsed, up)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", filepath.Base(migration.Source), err)
+		}
+	case up:
+		err = migration.UpContext(ctx, db)
+	default:
+		err = migration.DownContext(ctx, db)
+	}
+	result.Duration = time.Since(start)
+
+	fields := map[string]interface{}{
+		"version":     result.Version,
+		"direction":   result.Direction(),
+		"duration_ms": result.Duration.Milliseconds(),
+		"statements":  result.Statements,
+	}
+	if err != nil {
+		tflog.Error(ctx, fmt.Sprintf("goose: failed to migrate %s", migration.Source), fields)
+		return result, err
+	}
+	tflog.Info(ctx, fmt.Sprintf("goose: migrated %s", migration.Source), fields)
+
+	if up {
+		// The migration is applied already, so a failure to describe it is not a failure to migrate.
+		if err := store.Record(ctx, db, result); err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("goose: failed to record history of %s: %v", migration.Source, err))
+		}
+	}
+	return result, nil
+}
+
+// runSQLMigration runs the statements of the SQL migration, then the bulk loads of an up migration,
+// and records the version. The version is written in the transaction of the last statements
+// unless there are bulk loads, which aren't transactional: then the last statements mark the bulk loads
+// as pending instead, and the version is recorded once they have finished. A run after a failed bulk load
+// skips the committed statements and reruns the loads, which upsert the same rows again.
+func runSQLMigration(ctx context.Context, db *sql.DB, store *Store, migration *goose.Migration, parsed *SQLMigration, up bool) error {
+	record := func(ctx context.Context, tx database.DBTxConn) error {
+		return store.setVersion(ctx, tx, migration.Version, up)
+	}
+	var loads []BulkLoad
+	if up {
+		var err error
+		loads, err = parsed.BulkLoads(migration.Source)
+		if err != nil {
+			return err
+		}
+	}
+	if len(loads) == 0 {
+		return runStatements(ctx, db, parsed.Statements(up), !parsed.NoTransaction, record)
+	}
+
+	pending, err := store.bulkLoadPending(ctx, db, migration.Version)
+	if err != nil {
+		return err
+	}
+	if pending {
+		tflog.Info(ctx, fmt.Sprintf("goose: the Up statements of %s are committed, resuming its bulk loads", migration.Source))
+	} else {
+		mark := func(ctx context.Context, tx database.DBTxConn) error {
+			return store.markBulkLoadPending(ctx, tx, migration.Version)
+		}
+		if err := runStatements(ctx, db, parsed.Statements(up), !parsed.NoTransaction, mark); err != nil {
+			return err
+		}
+	}
+	if err := runBulkLoads(ctx, db, loads); err != nil {
+		return fmt.Errorf("%w (the Up statements are committed; the next apply skips them and reruns the bulk loads)", err)
+	}
+	return store.finishBulkLoad(ctx, db, migration.Version)
+}
+
+// UpTo applies the pending migrations of the set up to, and including, the given version.
+func UpTo(ctx context.Context, db *sql.DB, store *Store, migrations goose.Migrations, version int64) ([]MigrationResult, error) {
+	current, err := store.EnsureVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.EnsureHistoryColumns(ctx, db); err != nil {
+		return nil, err
+	}
+
+	var results []MigrationResult
+	for _, migration := range migrations {
+		if migration.Version <= current || migration.Version > version {
+			continue
+		}
+		result, err := ApplyMigration(ctx, db, store, migration, true, false)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		current = migration.Version
+	}
+	tflog.Info(ctx, fmt.Sprintf("goose: migrated database to version: %d", current))
+	return results, nil
+}
+
+// Baseline records the migrations of the set up to, and including, the given version as applied without
+// running them, for a database whose schema was created another way. It reports false, and records nothing,
+// if the version table has any migration recorded already.
+func Baseline(ctx context.Context, db *sql.DB, store *Store, migrations goose.Migrations, version int64) (bool, error) {
+	if _, err := migrations.Current(version); err != nil {
+		return false, fmt.Errorf("baseline version %d is not the version of a migration: %w", version, err)
+	}
+	if _, err := store.EnsureVersion(ctx, db); err != nil {
+		return false, err
+	}
+	if err := store.EnsureHistoryColumns(ctx, db); err != nil {
+		return false, err
+	}
+	recorded, err := store.ListMigrations(ctx, db)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range recorded {
+		if m.Version > 0 {
+			tflog.Info(ctx, fmt.Sprintf("goose: not recording baseline version %d, %s has versions recorded", version, store.Tablename()))
+			return false, nil
+		}
+	}
+
+	var baseline goose.Migrations
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			baseline = append(baseline, migration)
+		}
+	}
+	err = inTx(ctx, db, func(tx *sql.Tx) error {
+		for _, migration := range baseline {
+			if err := store.setVersion(ctx, tx, migration.Version, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, migration := range baseline {
+		if err := store.Record(ctx, db, MigrationResult{Version: migration.Version, Source: migration.Source, Up: true}); err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("goose: failed to record history of %s: %v", migration.Source, err))
+		}
+	}
+	tflog.Info(ctx, fmt.Sprintf("goose: recorded %d migrations up to version %d as applied without running them", len(baseline), version))
+	return true, nil
+}
+
+// DownTo rolls back the applied migrations of the set down to, but not including, the given version.
+// It fails at a migration without Down statements unless allowIrreversible is set.
+func DownTo(ctx context.Context, db *sql.DB, store *Store, migrations goose.Migrations, version int64, allowIrreversible bool) ([]MigrationResult, error) {
+	var results []MigrationResult
+	for {
+		current, err := store.EnsureVersion(ctx, db)
+		if err != nil {
+			return results, err
+		}
+		if current <= version {
+			tflog.Info(ctx, fmt.Sprintf("goose: migrated database to version: %d", current))
+			return results, nil
+		}
+
+		migration, err := migrations.Current(current)
+		if err != nil {
+			return results, fmt.Errorf("migration file not found for current version (%d): %w", current, err)
+		}
+		result, err := ApplyMigration(ctx, db, store, migration, false, allowIrreversible)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+}
+
+// Redo rolls back the migrations of the last count versions applied in the database and applies them again.
+// It runs nothing if one of them has no Down statements.
+func Redo(ctx context.Context, db *sql.DB, store *Store, migrations goose.Migrations, count int64) ([]MigrationResult, error) {
+	_, applied, err := store.AppliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	redoMigrations, err := RedoMigrations(migrations, applied, count)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckRedoable(redoMigrations); err != nil {
+		return nil, err
+	}
+
+	var results []MigrationResult
+	for i := len(redoMigrations) - 1; i >= 0; i-- {
+		result, err := ApplyMigration(ctx, db, store, redoMigrations[i], false, false)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	for _, migration := range redoMigrations {
+		result, err := ApplyMigration(ctx, db, store, migration, true, false)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SlowMigrations returns the results which took longer than SlowMigrationThreshold.
+func SlowMigrations(results []MigrationResult) []MigrationResult {
+	var slow []MigrationResult
+	for _, result := range results {
+		if result.Duration >= SlowMigrationThreshold {
+			slow = append(slow, result)
+		}
+	}
+	return slow
+}
@@ -0,0 +1,347 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func writeSQLMigrations(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestUpToDownTo(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_payments.sql": "-- +goose Up\nCREATE TABLE payments (id INTEGER);\n-- +goose Down\nDROP TABLE payments;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock := LockMigrations("")
+	defer unlock()
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		up      bool
+		version int64
+	}{
+		{up: true, version: 1},
+		{up: true, version: 2},
+		{up: false, version: 1},
+		{up: false, version: 0},
+	}
+	for _, step := range steps {
+		var results []MigrationResult
+		if step.up {
+			results, err = UpTo(ctx, db, store, migrations, step.version)
+		} else {
+			results, err = DownTo(ctx, db, store, migrations, step.version, false)
+		}
+		if err != nil {
+			t.Fatalf("migrating to %d: %v", step.version, err)
+		}
+		current, err := store.Version(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current != step.version {
+			t.Errorf("version = %d, want %d", current, step.version)
+		}
+		if len(results) != 1 || results[0].Up != step.up || results[0].Statements != 1 {
+			t.Errorf("results = %+v, want one %s migration with one statement", results, map[bool]string{true: "up", false: "down"}[step.up])
+		}
+	}
+}
+
+func TestUpToRollsBackFailedTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n",
+		"002_backfill.sql": "-- +goose Up\nINSERT INTO orders VALUES (1);\nINSERT INTO missing VALUES (1);\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock := LockMigrations("")
+	defer unlock()
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpTo(ctx, db, store, migrations, 2); err == nil {
+		t.Fatal("UpTo() succeeded, want the error of 002_backfill.sql")
+	}
+	current, err := store.Version(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != 1 {
+		t.Errorf("version = %d, want 1", current)
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("orders has %d rows, want the insert rolled back", count)
+	}
+}
+
+func TestUpToMixedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql": "-- +goose Up\n-- +goose StatementBegin\nCREATE TABLE orders (id INTEGER);\nINSERT INTO orders VALUES (1);\n-- +goose StatementEnd\n" +
+			"CREATE TABLE payments (id INTEGER);\nINSERT INTO payments VALUES (1);\n",
+		"002_broken.sql": "-- +goose Up\nINSERT INTO orders VALUES (2);\nCREATE TABLE refunds (id INTEGER);\nINSERT INTO missing VALUES (1);\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock := LockMigrations("")
+	defer unlock()
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = UpTo(ctx, db, store, migrations, 2)
+	if err == nil || !strings.Contains(err.Error(), "line 4:") || !strings.Contains(err.Error(), "lines 2-3 committed before the failure") {
+		t.Fatalf("UpTo() error = %v, want the failure of line 4 after lines 2-3", err)
+	}
+	current, err := store.Version(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != 1 {
+		t.Errorf("version = %d, want 1", current)
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("orders has %d rows, want 2", count)
+	}
+}
+
+func TestAppliedVersions(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_payments.sql": "-- +goose Up\nCREATE TABLE payments (id INTEGER);\n-- +goose Down\nDROP TABLE payments;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, applied, err := store.AppliedVersions(ctx, db)
+	if err != nil || current != 0 || len(applied) != 0 {
+		t.Fatalf("AppliedVersions() without a version table = %d, %v, %v", current, applied, err)
+	}
+	if _, err := db.ExecContext(ctx, "CREATE TABLE goose_db_version (id INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.AppliedVersions(ctx, db); err == nil {
+		t.Fatal("AppliedVersions() treats an unreadable version table as a missing one")
+	}
+	if _, err := db.ExecContext(ctx, "DROP TABLE goose_db_version"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpTo(ctx, db, store, migrations, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DownTo(ctx, db, store, migrations, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	current, applied, err = store.AppliedVersions(ctx, db)
+	if err != nil || current != 1 || len(applied) != 1 || applied[0] != 1 {
+		t.Fatalf("AppliedVersions() = %d, %v, %v, want 1, [1]", current, applied, err)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_payments.sql": "-- +goose Up\nCREATE TABLE payments (id INTEGER);\n-- +goose Down\nDROP TABLE payments;\n",
+		"003_refunds.sql":  "-- +goose Up\nCREATE TABLE refunds (id INTEGER);\n-- +goose Down\nDROP TABLE refunds;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The schema up to the baseline exists already, so running its migrations would fail.
+	if _, err := db.ExecContext(ctx, "CREATE TABLE orders (id INTEGER); CREATE TABLE payments (id INTEGER);"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Baseline(ctx, db, store, migrations, 4); err == nil {
+		t.Error("Baseline() at a version without a migration succeeded")
+	}
+	if baselined, err := Baseline(ctx, db, store, migrations, 2); err != nil || !baselined {
+		t.Fatalf("Baseline() = %t, %v", baselined, err)
+	}
+	results, err := UpTo(ctx, db, store, migrations, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Version != 3 {
+		t.Errorf("UpTo() after the baseline applied %+v, want only version 3", results)
+	}
+	history, err := store.ListHistory(ctx, db)
+	if err != nil || len(history) != 3 || history[0].FileName != "001_orders.sql" {
+		t.Errorf("ListHistory() = %+v, %v", history, err)
+	}
+
+	if baselined, err := Baseline(ctx, db, store, migrations, 2); err != nil || baselined {
+		t.Errorf("Baseline() with versions recorded = %t, %v, want false", baselined, err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	// Baseline records its versions in one transaction, so they may share a timestamp and be listed in any order.
+	current, applied, found := appliedVersions([]*database.ListMigrationsResult{
+		{Version: 1, IsApplied: true},
+		{Version: 3, IsApplied: false},
+		{Version: 2, IsApplied: true},
+		{Version: 3, IsApplied: true},
+		{Version: 0, IsApplied: true},
+	})
+	if !found || current != 2 || !reflect.DeepEqual(applied, []int64{1, 2}) {
+		t.Errorf("appliedVersions() = %d, %v, %t, want 2, [1 2], true", current, applied, found)
+	}
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateVersionTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := store.EnsureVersion(ctx, db); err != nil || version != 0 {
+		t.Errorf("EnsureVersion() of an empty version table = %d, %v, want 0, nil", version, err)
+	}
+}
+
+func TestDownToIrreversible(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_backfill.sql": "-- +goose Up\nINSERT INTO orders (id) VALUES (1);\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpTo(ctx, db, store, migrations, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DownTo(ctx, db, store, migrations, 0, false); err == nil || !strings.Contains(err.Error(), "002_backfill.sql has no Down statements") {
+		t.Fatalf("DownTo() = %v, want the irreversible migration to stop it", err)
+	}
+	if _, err := Redo(ctx, db, store, migrations, 1); err == nil || !strings.Contains(err.Error(), "002_backfill.sql") {
+		t.Fatalf("Redo() = %v, want the irreversible migration to stop it", err)
+	}
+	if version, err := store.Version(ctx, db); err != nil || version != 2 {
+		t.Fatalf("version after the refused rollbacks = %d, %v, want 2", version, err)
+	}
+	if _, err := DownTo(ctx, db, store, migrations, 0, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedoAppliedVersions(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_orders.sql":   "-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n",
+		"002_payments.sql": "-- +goose Up\nCREATE TABLE payments (id INTEGER);\n-- +goose Down\nDROP TABLE payments;\n",
+		"003_refunds.sql":  "-- +goose Up\nCREATE TABLE refunds (id INTEGER);\n-- +goose Down\nDROP TABLE refunds;\n",
+	})
+	migrations, err := CollectMigrations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UpTo(ctx, db, store, migrations, 1); err != nil {
+		t.Fatal(err)
+	}
+	// 003 is applied out of order, 002 never ran.
+	if _, err := ApplyMigration(ctx, db, store, migrations[2], true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Redo(ctx, db, store, migrations, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var redone []int64
+	for _, result := range results {
+		redone = append(redone, result.Version)
+	}
+	if !reflect.DeepEqual(redone, []int64{3, 1, 1, 3}) {
+		t.Errorf("Redo() ran versions %v, want 3, 1 down and 1, 3 up", redone)
+	}
+	if _, applied, err := store.AppliedVersions(ctx, db); err != nil || !reflect.DeepEqual(applied, []int64{1, 3}) {
+		t.Errorf("AppliedVersions() after the redo = %v, %v, want [1 3]", applied, err)
+	}
+}
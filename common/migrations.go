@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+)
+
+type attributeGetter interface {
+	GetAttribute(ctx context.Context, path path.Path, target interface{}) diag.Diagnostics
+}
+
+// CollectMigrations collects the migrations of every directory into one set ordered by version.
+// The same version defined in two directories is an error.
+func CollectMigrations(dirs []string) (goose.Migrations, error) {
+	var migrations goose.Migrations
+	seen := make(map[int64]string)
+	for _, dir := range dirs {
+		found, err := goose.CollectMigrations(dir, 0, maxVersion)
+		if err != nil && !errors.Is(err, goose.ErrNoMigrationFiles) {
+			return nil, err
+		}
+		for _, migration := range found {
+			if source, ok := seen[migration.Version]; ok {
+				return nil, fmt.Errorf("duplicate migration version %d: %s and %s", migration.Version, source, migration.Source)
+			}
+			seen[migration.Version] = migration.Source
+			migrations = append(migrations, migration)
+		}
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, migration := range migrations {
+		migration.Previous = -1
+		migration.Next = -1
+		if i > 0 {
+			migration.Previous = migrations[i-1].Version
+			migrations[i-1].Next = migration.Version
+		}
+	}
+	return migrations, nil
+}
+
+// MigrationFile is a goose migration file name split into its parts.
+type MigrationFile struct {
+	Version     int64
+	Description string
+	// Type is "sql" or "go".
+	Type string
+}
+
+// ParseMigrationFilename parses a goose migration file name like `00002_add_orders.sql`.
+func ParseMigrationFilename(name string) (MigrationFile, error) {
+	version, err := goose.NumericComponent(name)
+	if err != nil {
+		return MigrationFile{}, err
+	}
+	base := filepath.Base(name)
+	ext := filepath.Ext(base)
+	return MigrationFile{
+		Version:     version,
+		Description: strings.TrimSuffix(base[strings.Index(base, "_")+1:], ext),
+		Type:        strings.TrimPrefix(ext, "."),
+	}, nil
+}
+
+// planMigrationsDirs reads the migration directories from the plan falling back to the state.
+func planMigrationsDirs(ctx context.Context, plan attributeGetter, state attributeGetter) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	dirs, d := migrationsDirs(ctx, plan)
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	if len(dirs) == 0 {
+		tflog.Debug(ctx, "no migrations_dir in plan")
+		dirs, _ = migrationsDirs(ctx, state)
+	}
+	if len(dirs) == 0 {
+		diags.AddError("migrations_dir", "one of migrations_dir or migrations_dirs is required")
+	}
+	return dirs, diags
+}
+
+func migrationsDirs(ctx context.Context, data attributeGetter) ([]string, diag.Diagnostics) {
+	var migrationsDir types.String
+	var migrationsDirsList types.List
+
+	diags := data.GetAttribute(ctx, path.Root("migrations_dir"), &migrationsDir)
+	diags.Append(data.GetAttribute(ctx, path.Root("migrations_dirs"), &migrationsDirsList)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	if migrationsDir.ValueString() != "" {
+		return []string{migrationsDir.ValueString()}, diags
+	}
+	if migrationsDirsList.IsNull() || migrationsDirsList.IsUnknown() {
+		return nil, diags
+	}
+	var dirs []string
+	diags.Append(migrationsDirsList.ElementsAs(ctx, &dirs, false)...)
+	return dirs, diags
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigrations(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("-- +goose Up\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCollectMigrations(t *testing.T) {
+	shared := writeMigrations(t, "001_audit.sql", "003_outbox.sql")
+	service := writeMigrations(t, "002_orders.sql", "004_payments.sql")
+
+	migrations, err := CollectMigrations([]string{shared, service})
+	if err != nil {
+		t.Fatalf("CollectMigrations() error = %v", err)
+	}
+	var versions []int64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	want := []int64{1, 2, 3, 4}
+	if len(versions) != len(want) {
+		t.Fatalf("CollectMigrations() versions = %v, want %v", versions, want)
+	}
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Fatalf("CollectMigrations() versions = %v, want %v", versions, want)
+		}
+	}
+	if migrations[1].Previous != 1 || migrations[1].Next != 3 {
+		t.Errorf("CollectMigrations() did not connect migrations: previous %d, next %d", migrations[1].Previous, migrations[1].Next)
+	}
+}
+
+func TestCollectMigrationsDuplicate(t *testing.T) {
+	shared := writeMigrations(t, "001_audit.sql")
+	service := writeMigrations(t, "001_orders.sql")
+
+	_, err := CollectMigrations([]string{shared, service})
+	if err == nil || !strings.Contains(err.Error(), "duplicate migration version 1") {
+		t.Fatalf("CollectMigrations() error = %v, want duplicate version error", err)
+	}
+}
+
+func TestParseMigrationFilename(t *testing.T) {
+	got, err := ParseMigrationFilename("migrations/00002_add_orders.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MigrationFile{Version: 2, Description: "add_orders", Type: "sql"}
+	if got != want {
+		t.Errorf("ParseMigrationFilename() = %+v, want %+v", got, want)
+	}
+
+	for _, name := range []string{"orders.sql", "001_orders.txt", "0_orders.sql"} {
+		if _, err := ParseMigrationFilename(name); err == nil {
+			t.Errorf("ParseMigrationFilename(%q) error = nil", name)
+		}
+	}
+}
@@ -10,6 +10,10 @@ var Descriptions = map[string]string{
 	"token": "The access token for API operations.",
 
 	"service_account_key_file": "Either the path to or the contents of a Service Account key file in JSON format.",
+	"profile": "The name of a yc CLI profile in `~/.config/yandex-cloud/config.yaml` to take the credentials " +
+		"and the endpoint from. Conflicts with `token` and `service_account_key_file`; YC_TOKEN and " +
+		"YC_SERVICE_ACCOUNT_KEY_FILE are ignored when it is set.",
+	"audit_log_path": "Path of a file every migration action is appended to as a JSON line.",
 	"max_retries": "The maximum number of times an API request is being executed. \n" +
 		"If the API request still fails, an error is thrown.",
 }
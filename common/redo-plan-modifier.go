@@ -0,0 +1,173 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
+)
+
+const DefaultRedoVersions = 1
+
+// RedoMigrations returns the migrations of the last count applied versions in the order of their versions.
+// An applied version without a migration file can't be redone.
+func RedoMigrations(migrations goose.Migrations, applied []int64, count int64) (goose.Migrations, error) {
+	if count < int64(len(applied)) {
+		applied = applied[int64(len(applied))-count:]
+	}
+	redo := make(goose.Migrations, 0, len(applied))
+	for _, version := range applied {
+		migration, err := migrations.Current(version)
+		if err != nil {
+			return nil, fmt.Errorf("can't redo version %d, it has no migration file", version)
+		}
+		redo = append(redo, migration)
+	}
+	return redo, nil
+}
+
+// AppliedVersionsAt returns the versions of the migrations at or below version, which are the versions applied
+// at it unless migrations were applied out of order. The plan assumes them if the database can't be read.
+func AppliedVersionsAt(migrations goose.Migrations, version int64) []int64 {
+	var applied []int64
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			applied = append(applied, migration.Version)
+		}
+	}
+	return applied
+}
+
+// AppliedVersionsAfter returns the versions applied after running the pending migrations up or down.
+func AppliedVersionsAfter(applied []int64, pending goose.Migrations, up bool) []int64 {
+	versions := make(map[int64]bool, len(applied)+len(pending))
+	for _, version := range applied {
+		versions[version] = true
+	}
+	for _, migration := range pending {
+		versions[migration.Version] = up
+	}
+	after := make([]int64, 0, len(versions))
+	for version, isApplied := range versions {
+		if isApplied {
+			after = append(after, version)
+		}
+	}
+	sort.Slice(after, func(i, j int) bool { return after[i] < after[j] })
+	return after
+}
+
+// CheckRedoable fails if a migration to redo has no Down statements: its Up statements would run again
+// on the schema they created, so skipping the Down statements isn't allowed for a redo.
+func CheckRedoable(redo goose.Migrations) error {
+	found, err := IrreversibleMigrations(redo)
+	if err != nil {
+		return err
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("can't redo migrations without Down statements, their Up statements would run again on the schema "+
+			"they created:\n%s", strings.Join(found, "\n"))
+	}
+	return nil
+}
+
+// CheckRedo fails the plan if the migrations to redo can't be rolled back, or contain destructive statements
+// and the resource does not set allow_destructive.
+func CheckRedo(ctx context.Context, plan attributeGetter, redo goose.Migrations) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if err := CheckRedoable(redo); err != nil {
+		diags.AddAttributeError(path.Root("redo_trigger"), "Irreversible migrations", err.Error())
+		return diags
+	}
+	return checkDestructiveStatements(ctx, plan, "The migrations to redo", func() ([]string, error) {
+		return RedoDestructiveStatements(redo)
+	})
+}
+
+type redoMigrationsPlanModifier struct{}
+
+func (m redoMigrationsPlanModifier) Description(_ context.Context) string {
+	return "Calculates the list of migrations to redo"
+}
+
+func (m redoMigrationsPlanModifier) MarkdownDescription(_ context.Context) string {
+	return "Calculates the list of migrations to redo"
+}
+
+func (m redoMigrationsPlanModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	if req.State.Raw.IsNull() {
+		resp.PlanValue = types.ListValueMust(types.StringType, nil)
+		return
+	}
+
+	var planTrigger, stateTrigger types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("redo_trigger"), &planTrigger)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("redo_trigger"), &stateTrigger)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if planTrigger.IsUnknown() {
+		resp.PlanValue = types.ListUnknown(types.StringType)
+		return
+	}
+	if planTrigger.IsNull() || planTrigger.Equal(stateTrigger) {
+		resp.PlanValue = req.StateValue
+		return
+	}
+
+	var count *int64
+	migrationsDirs, diags := planMigrationsDirs(ctx, req.Plan, req.State)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("redo_versions"), &count)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	migrations, err := CollectMigrations(migrationsDirs)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect migrations", err.Error())
+		return
+	}
+
+	version, _, diags := plannedVersion(ctx, req.Plan, req.State, migrations)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	redoVersions := int64(DefaultRedoVersions)
+	if count != nil {
+		redoVersions = *count
+	}
+
+	// The resource plans the redo again against the versions applied in the database and checks it.
+	redoMigrations, err := RedoMigrations(migrations, AppliedVersionsAt(migrations, version), redoVersions)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to plan the redo", err.Error())
+		return
+	}
+	val, diags := RedoList(ctx, redoMigrations)
+	resp.Diagnostics.Append(diags...)
+	resp.PlanValue = val
+}
+
+// RedoList returns the sources of the migrations to redo.
+func RedoList(ctx context.Context, redo goose.Migrations) (types.List, diag.Diagnostics) {
+	sources := []string{}
+	for _, migration := range redo {
+		sources = append(sources, migration.String())
+	}
+	return types.ListValueFrom(ctx, types.StringType, sources)
+}
+
+func RedoMigrationsPlanModifier() planmodifier.List {
+	return redoMigrationsPlanModifier{}
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+)
+
+func TestRedoMigrations(t *testing.T) {
+	migrations := goose.Migrations{
+		{Version: 1, Source: "migrations/01_a.sql"},
+		{Version: 2, Source: "migrations/02_b.sql"},
+		{Version: 3, Source: "migrations/03_c.sql"},
+	}
+	tests := []struct {
+		name    string
+		applied []int64
+		count   int64
+		want    []int64
+	}{
+		{name: "latest", applied: []int64{1, 2, 3}, count: 1, want: []int64{3}},
+		{name: "range", applied: []int64{1, 2, 3}, count: 2, want: []int64{2, 3}},
+		{name: "gap", applied: []int64{1, 3}, count: 2, want: []int64{1, 3}},
+		{name: "more than applied", applied: []int64{1, 2}, count: 5, want: []int64{1, 2}},
+		{name: "nothing applied", applied: nil, count: 1, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RedoMigrations(migrations, tt.applied, tt.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("RedoMigrations() = %v, want versions %v", got, tt.want)
+			}
+			for i, m := range got {
+				if m.Version != tt.want[i] {
+					t.Errorf("RedoMigrations()[%d] = %d, want %d", i, m.Version, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := RedoMigrations(migrations, []int64{1, 4}, 1); err == nil {
+		t.Error("RedoMigrations() of a version without a file succeeded")
+	}
+}
+
+func TestAppliedVersionsAfter(t *testing.T) {
+	migrations := goose.Migrations{{Version: 1}, {Version: 2}, {Version: 3}}
+	if got := AppliedVersionsAt(migrations, 2); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("AppliedVersionsAt() = %v, want [1 2]", got)
+	}
+	if got := AppliedVersionsAfter([]int64{1, 3}, migrations[1:2], true); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("AppliedVersionsAfter() up = %v, want [1 2 3]", got)
+	}
+	if got := AppliedVersionsAfter([]int64{1, 3}, goose.Migrations{migrations[2]}, false); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("AppliedVersionsAfter() down = %v, want [1]", got)
+	}
+}
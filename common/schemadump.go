@@ -0,0 +1,259 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+	"github.com/ydb-platform/ydb-go-sdk/v3/scheme"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SchemeTree lists the scheme tree of a database and describes its tables.
+// Paths are relative to the database.
+type SchemeTree interface {
+	ListDirectory(ctx context.Context, path string) ([]scheme.Entry, error)
+	DescribeTable(ctx context.Context, path string) (options.Description, error)
+}
+
+var openSchemeTree = openYDBSchemeTree
+
+func openYDBSchemeTree(db *sql.DB) (SchemeTree, error) {
+	driver, err := ydb.Unwrap(db)
+	if err != nil {
+		return nil, fmt.Errorf("dumping the schema requires a YDB database: %w", err)
+	}
+	return &ydbSchemeTree{driver: driver}, nil
+}
+
+// DumpSchema renders the tables under dir, relative to the database, as YQL keyed by their path.
+// The tables named in exclude, e.g. the version table, and hidden directories such as .sys are left out.
+func DumpSchema(ctx context.Context, db *sql.DB, dir string, exclude []string) (map[string]string, error) {
+	tree, err := openSchemeTree(db)
+	if err != nil {
+		return nil, err
+	}
+	return DumpSchemeTree(ctx, tree, dir, exclude)
+}
+
+// DumpSchemeTree is DumpSchema on the tables of tree.
+func DumpSchemeTree(ctx context.Context, tree SchemeTree, dir string, exclude []string) (map[string]string, error) {
+	excluded := make(map[string]bool, len(exclude))
+	for _, p := range exclude {
+		excluded[strings.Trim(p, "/")] = true
+	}
+
+	tables := map[string]string{}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := tree.ListDirectory(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("failed to list %q: %w", "/"+dir, err)
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		for _, entry := range entries {
+			p := path.Join(dir, entry.Name)
+			switch {
+			case strings.HasPrefix(entry.Name, ".") || excluded[p]:
+				continue
+			case entry.IsDirectory():
+				if err := walk(p); err != nil {
+					return err
+				}
+			case entry.IsTable() || entry.IsColumnTable():
+				description, err := tree.DescribeTable(ctx, p)
+				if err != nil {
+					return fmt.Errorf("failed to describe %q: %w", p, err)
+				}
+				tables[p] = CreateTableYQL(p, description, entry.IsColumnTable())
+			default:
+				tflog.Debug(ctx, fmt.Sprintf("goose: not dumping %s, a %s", p, entry.Type))
+			}
+		}
+		return nil
+	}
+	if err := walk(strings.Trim(dir, "/")); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// SchemaYQL joins the YQL of the tables in the order of their paths.
+func SchemaYQL(tables map[string]string) string {
+	paths := make([]string, 0, len(tables))
+	for p := range tables {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	yql := make([]string, 0, len(paths))
+	for _, p := range paths {
+		yql = append(yql, tables[p])
+	}
+	return strings.Join(yql, "\n\n")
+}
+
+// CreateTableYQL renders the description of a table as a CREATE TABLE statement, followed by
+// an ALTER TABLE statement for every changefeed. Indexes and changefeeds are ordered by name.
+func CreateTableYQL(tablePath string, description options.Description, columnStore bool) string {
+	var lines []string
+	for _, column := range description.Columns {
+		typ := column.Type
+		notNull := " NOT NULL"
+		if optional, inner := types.IsOptional(typ); optional {
+			typ, notNull = inner, ""
+		}
+		lines = append(lines, fmt.Sprintf("%s %s%s", quoteIdentifier(column.Name), typ.Yql(), notNull))
+	}
+	lines = append(lines, fmt.Sprintf("PRIMARY KEY (%s)", quoteIdentifiers(description.PrimaryKey)))
+
+	indexes := append([]options.IndexDescription(nil), description.Indexes...)
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	for _, index := range indexes {
+		kind := "GLOBAL"
+		if index.Type == options.IndexTypeGlobalAsync {
+			kind = "GLOBAL ASYNC"
+		}
+		line := fmt.Sprintf("INDEX %s %s ON (%s)", quoteIdentifier(index.Name), kind, quoteIdentifiers(index.IndexColumns))
+		if len(index.DataColumns) > 0 {
+			line += fmt.Sprintf(" COVER (%s)", quoteIdentifiers(index.DataColumns))
+		}
+		lines = append(lines, line)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE `%s` (\n    %s\n)", tablePath, strings.Join(lines, ",\n    "))
+	if settings := tableSettings(description, columnStore); len(settings) > 0 {
+		fmt.Fprintf(&b, "\nWITH (\n    %s\n)", strings.Join(settings, ",\n    "))
+	}
+	b.WriteString(";")
+
+	changefeeds := append([]options.ChangefeedDescription(nil), description.Changefeeds...)
+	sort.Slice(changefeeds, func(i, j int) bool { return changefeeds[i].Name < changefeeds[j].Name })
+	for _, changefeed := range changefeeds {
+		var settings []string
+		if mode := changefeedModes[changefeed.Mode]; mode != "" {
+			settings = append(settings, fmt.Sprintf("MODE = '%s'", mode))
+		}
+		if format := changefeedFormats[changefeed.Format]; format != "" {
+			settings = append(settings, fmt.Sprintf("FORMAT = '%s'", format))
+		}
+		fmt.Fprintf(&b, "\n\nALTER TABLE `%s` ADD CHANGEFEED %s", tablePath, quoteIdentifier(changefeed.Name))
+		if len(settings) > 0 {
+			fmt.Fprintf(&b, " WITH (%s)", strings.Join(settings, ", "))
+		}
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+var (
+	changefeedModes = map[options.ChangefeedMode]string{
+		options.ChangefeedModeKeysOnly:        "KEYS_ONLY",
+		options.ChangefeedModeUpdates:         "UPDATES",
+		options.ChangefeedModeNewImage:        "NEW_IMAGE",
+		options.ChangefeedModeOldImage:        "OLD_IMAGE",
+		options.ChangefeedModeNewAndOldImages: "NEW_AND_OLD_IMAGES",
+	}
+	changefeedFormats = map[options.ChangefeedFormat]string{
+		options.ChangefeedFormatJSON:                "JSON",
+		options.ChangefeedFormatDynamoDBStreamsJSON: "DYNAMODB_STREAMS_JSON",
+	}
+	ttlUnits = map[options.TimeToLiveUnit]string{
+		options.TimeToLiveUnitSeconds:      "SECONDS",
+		options.TimeToLiveUnitMilliseconds: "MILLISECONDS",
+		options.TimeToLiveUnitMicroseconds: "MICROSECONDS",
+		options.TimeToLiveUnitNanoseconds:  "NANOSECONDS",
+	}
+)
+
+// tableSettings returns the WITH settings of the table: its store, partitioning and TTL.
+func tableSettings(description options.Description, columnStore bool) []string {
+	var settings []string
+	if columnStore {
+		settings = append(settings, "STORE = COLUMN")
+	}
+	partitioning := description.PartitioningSettings
+	if flag := featureFlag(partitioning.PartitioningBySize); flag != "" {
+		settings = append(settings, "AUTO_PARTITIONING_BY_SIZE = "+flag)
+	}
+	if partitioning.PartitionSizeMb > 0 {
+		settings = append(settings, fmt.Sprintf("AUTO_PARTITIONING_PARTITION_SIZE_MB = %d", partitioning.PartitionSizeMb))
+	}
+	if flag := featureFlag(partitioning.PartitioningByLoad); flag != "" {
+		settings = append(settings, "AUTO_PARTITIONING_BY_LOAD = "+flag)
+	}
+	if partitioning.MinPartitionsCount > 0 {
+		settings = append(settings, fmt.Sprintf("AUTO_PARTITIONING_MIN_PARTITIONS_COUNT = %d", partitioning.MinPartitionsCount))
+	}
+	if partitioning.MaxPartitionsCount > 0 {
+		settings = append(settings, fmt.Sprintf("AUTO_PARTITIONING_MAX_PARTITIONS_COUNT = %d", partitioning.MaxPartitionsCount))
+	}
+	if ttl := description.TimeToLiveSettings; ttl != nil {
+		setting := fmt.Sprintf("TTL = Interval(\"PT%dS\") ON %s", ttl.ExpireAfterSeconds, quoteIdentifier(ttl.ColumnName))
+		if ttl.Mode == options.TimeToLiveModeValueSinceUnixEpoch && ttl.ColumnUnit != nil {
+			setting += " AS " + ttlUnits[*ttl.ColumnUnit]
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+func featureFlag(flag options.FeatureFlag) string {
+	switch flag {
+	case options.FeatureEnabled:
+		return "ENABLED"
+	case options.FeatureDisabled:
+		return "DISABLED"
+	}
+	return ""
+}
+
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return "`" + name + "`"
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, quoteIdentifier(name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+type ydbSchemeTree struct {
+	driver *ydb.Driver
+}
+
+func (t *ydbSchemeTree) fullPath(p string) string {
+	return path.Join(t.driver.Name(), p)
+}
+
+func (t *ydbSchemeTree) ListDirectory(ctx context.Context, p string) ([]scheme.Entry, error) {
+	dir, err := t.driver.Scheme().ListDirectory(ctx, t.fullPath(p))
+	if err != nil {
+		return nil, err
+	}
+	return dir.Children, nil
+}
+
+func (t *ydbSchemeTree) DescribeTable(ctx context.Context, p string) (options.Description, error) {
+	var description options.Description
+	err := t.driver.Table().Do(ctx, func(ctx context.Context, s table.Session) (err error) {
+		description, err = s.DescribeTable(ctx, t.fullPath(p))
+		return err
+	}, table.WithIdempotent())
+	return description, err
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ydb-platform/ydb-go-sdk/v3/scheme"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+)
+
+type fakeSchemeTree struct {
+	dirs   map[string][]scheme.Entry
+	tables map[string]options.Description
+}
+
+func (f fakeSchemeTree) ListDirectory(_ context.Context, path string) ([]scheme.Entry, error) {
+	return f.dirs[path], nil
+}
+
+func (f fakeSchemeTree) DescribeTable(_ context.Context, path string) (options.Description, error) {
+	return f.tables[path], nil
+}
+
+func TestCreateTableYQL(t *testing.T) {
+	seconds := options.TimeToLiveUnitSeconds
+	description := options.Description{
+		Columns: []options.Column{
+			{Name: "id", Type: types.TypeUint64},
+			{Name: "status", Type: types.Optional(types.TypeUTF8)},
+			{Name: "created at", Type: types.Optional(types.TypeUint32)},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []options.IndexDescription{
+			{Name: "by_status", IndexColumns: []string{"status"}, DataColumns: []string{"created at"}, Type: options.IndexTypeGlobalAsync},
+			{Name: "by_date", IndexColumns: []string{"created at"}},
+		},
+		PartitioningSettings: options.PartitioningSettings{
+			PartitioningBySize: options.FeatureEnabled,
+			PartitionSizeMb:    2048,
+			PartitioningByLoad: options.FeatureDisabled,
+			MinPartitionsCount: 1,
+		},
+		TimeToLiveSettings: &options.TimeToLiveSettings{
+			ColumnName:         "created at",
+			Mode:               options.TimeToLiveModeValueSinceUnixEpoch,
+			ExpireAfterSeconds: 86400,
+			ColumnUnit:         &seconds,
+		},
+		Changefeeds: []options.ChangefeedDescription{
+			{Name: "updates", Mode: options.ChangefeedModeUpdates, Format: options.ChangefeedFormatJSON},
+		},
+	}
+
+	want := "CREATE TABLE `shop/orders` (\n" +
+		"    id Uint64 NOT NULL,\n" +
+		"    status Utf8,\n" +
+		"    `created at` Uint32,\n" +
+		"    PRIMARY KEY (id),\n" +
+		"    INDEX by_date GLOBAL ON (`created at`),\n" +
+		"    INDEX by_status GLOBAL ASYNC ON (status) COVER (`created at`)\n" +
+		")\n" +
+		"WITH (\n" +
+		"    AUTO_PARTITIONING_BY_SIZE = ENABLED,\n" +
+		"    AUTO_PARTITIONING_PARTITION_SIZE_MB = 2048,\n" +
+		"    AUTO_PARTITIONING_BY_LOAD = DISABLED,\n" +
+		"    AUTO_PARTITIONING_MIN_PARTITIONS_COUNT = 1,\n" +
+		"    TTL = Interval(\"PT86400S\") ON `created at` AS SECONDS\n" +
+		");\n" +
+		"\n" +
+		"ALTER TABLE `shop/orders` ADD CHANGEFEED updates WITH (MODE = 'UPDATES', FORMAT = 'JSON');"
+	if got := CreateTableYQL("shop/orders", description, false); got != want {
+		t.Errorf("CreateTableYQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDumpSchema(t *testing.T) {
+	table := options.Description{
+		Columns:    []options.Column{{Name: "id", Type: types.TypeUint64}},
+		PrimaryKey: []string{"id"},
+	}
+	tree := fakeSchemeTree{
+		dirs: map[string][]scheme.Entry{
+			"": {
+				{Name: "shop", Type: scheme.EntryDirectory},
+				{Name: "goose_db_version", Type: scheme.EntryTable},
+				{Name: ".sys", Type: scheme.EntryDirectory},
+				{Name: "events", Type: scheme.EntryColumnTable},
+			},
+			"shop": {
+				{Name: "payments", Type: scheme.EntryTable},
+				{Name: "orders", Type: scheme.EntryTable},
+				{Name: "feed", Type: scheme.EntryTopic},
+			},
+		},
+		tables: map[string]options.Description{"events": table, "shop/orders": table, "shop/payments": table},
+	}
+
+	tables, err := DumpSchemeTree(context.Background(), tree, "/", []string{"goose_db_version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"events":        "CREATE TABLE `events` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n)\nWITH (\n    STORE = COLUMN\n);",
+		"shop/orders":   "CREATE TABLE `shop/orders` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);",
+		"shop/payments": "CREATE TABLE `shop/payments` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);",
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("DumpSchemeTree() = %q, want %q", tables, want)
+	}
+	if got, want := SchemaYQL(tables), want["events"]+"\n\n"+want["shop/orders"]+"\n\n"+want["shop/payments"]; got != want {
+		t.Errorf("SchemaYQL() = %q, want %q", got, want)
+	}
+
+	tables, err = DumpSchemeTree(context.Background(), tree, "shop", nil)
+	if err != nil || len(tables) != 2 {
+		t.Errorf("DumpSchemeTree() of a directory = %q, %v, want its 2 tables", tables, err)
+	}
+}
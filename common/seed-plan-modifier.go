@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type seedChecksumsPlanModifier struct{}
+
+func (m seedChecksumsPlanModifier) Description(_ context.Context) string {
+	return "Calculates the checksums of the seed scripts"
+}
+
+func (m seedChecksumsPlanModifier) MarkdownDescription(_ context.Context) string {
+	return "Calculates the checksums of the seed scripts"
+}
+
+func (m seedChecksumsPlanModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var dir types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("seeds_dir"), &dir)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if dir.IsUnknown() {
+		resp.PlanValue = types.MapUnknown(types.StringType)
+		return
+	}
+
+	seeds, err := CollectSeeds(dir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect seeds", err.Error())
+		return
+	}
+	checksums, err := SeedChecksums(seeds)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read seeds", err.Error())
+		return
+	}
+	val, diag := types.MapValueFrom(ctx, types.StringType, checksums)
+	resp.Diagnostics.Append(diag...)
+	resp.PlanValue = val
+}
+
+func SeedChecksumsPlanModifier() planmodifier.Map {
+	return seedChecksumsPlanModifier{}
+}
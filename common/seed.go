@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+// CollectSeeds returns the seed scripts of the directory ordered by their version prefix.
+// Seeds are named like migrations, but are never recorded in a version table.
+func CollectSeeds(dir string) (goose.Migrations, error) {
+	return CollectMigrations([]string{dir})
+}
+
+// SeedChecksums returns the sha256 checksums of the seed scripts keyed by their file name.
+func SeedChecksums(seeds goose.Migrations) (map[string]string, error) {
+	checksums := make(map[string]string, len(seeds))
+	for _, seed := range seeds {
+		checksum, err := FileChecksum(seed.Source)
+		if err != nil {
+			return nil, err
+		}
+		checksums[filepath.Base(seed.Source)] = checksum
+	}
+	return checksums, nil
+}
+
+// FileChecksum returns the hex encoded sha256 of the file.
+func FileChecksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ChangedSeeds returns the seeds whose checksum differs from the applied one, in run order.
+func ChangedSeeds(seeds goose.Migrations, checksums map[string]string, applied map[string]string) goose.Migrations {
+	var changed goose.Migrations
+	for _, seed := range seeds {
+		name := filepath.Base(seed.Source)
+		if applied[name] != checksums[name] {
+			changed = append(changed, seed)
+		}
+	}
+	return changed
+}
+
+// ApplySeeds runs the Up statements of the seed scripts of dir in version order with goose in no-versioning
+// mode: nothing is read from or written to a version table. The other files of dir are left out.
+// It returns the results of the seeds which ran before a failure. An empty dialect is YDB.
+func ApplySeeds(ctx context.Context, db *sql.DB, dialect database.Dialect, dir string, seeds goose.Migrations) ([]MigrationResult, error) {
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+	if dialect == "" {
+		dialect = database.DialectYdB
+	}
+	run := make(map[string]bool, len(seeds))
+	for _, seed := range seeds {
+		run[filepath.Base(seed.Source)] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var exclude []string
+	for _, entry := range entries {
+		if !run[entry.Name()] {
+			exclude = append(exclude, entry.Name())
+		}
+	}
+
+	provider, err := goose.NewProvider(dialect, db, os.DirFS(dir),
+		goose.WithDisableVersioning(true),
+		goose.WithDisableGlobalRegistry(true),
+		goose.WithExcludeNames(exclude),
+	)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := provider.Up(ctx)
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		applied = partial.Applied
+		name := filepath.Base(partial.Failed.Source.Path)
+		tflog.Error(ctx, fmt.Sprintf("goose: failed to seed %s", filepath.Join(dir, name)), map[string]interface{}{
+			"version": partial.Failed.Source.Version,
+		})
+		err = fmt.Errorf("%s: %w", name, partial.Err)
+	}
+
+	results := make([]MigrationResult, 0, len(applied))
+	for _, r := range applied {
+		result := MigrationResult{
+			Version:  r.Source.Version,
+			Source:   filepath.Join(dir, r.Source.Path),
+			Up:       true,
+			Duration: r.Duration,
+		}
+		tflog.Info(ctx, fmt.Sprintf("goose: seeded %s", result.Source), map[string]interface{}{
+			"version":     result.Version,
+			"duration_ms": result.Duration.Milliseconds(),
+		})
+		results = append(results, result)
+	}
+	return results, err
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+func TestSeeds(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if _, err := db.Exec(`CREATE TABLE currencies (code TEXT PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_currencies.sql": "-- +goose Up\nINSERT OR REPLACE INTO currencies VALUES ('EUR', 'Euro');\n",
+		"002_flags.sql":      "-- +goose Up\nCREATE TABLE IF NOT EXISTS flags (name TEXT PRIMARY KEY);\n",
+	})
+
+	seeds, err := CollectSeeds(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := SeedChecksums(seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := ApplySeeds(ctx, db, database.DialectSQLite3, dir, ChangedSeeds(seeds, applied, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[1].Source != filepath.Join(dir, "002_flags.sql") {
+		t.Errorf("ApplySeeds() = %+v, want both seeds", results)
+	}
+
+	content := "-- +goose Up\nINSERT OR REPLACE INTO currencies VALUES ('EUR', 'Euro');\nINSERT OR REPLACE INTO currencies VALUES ('USD', 'US Dollar');\n"
+	if err := os.WriteFile(filepath.Join(dir, "001_currencies.sql"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checksums, err := SeedChecksums(seeds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := ChangedSeeds(seeds, checksums, applied)
+	if len(changed) != 1 || filepath.Base(changed[0].Source) != "001_currencies.sql" {
+		t.Fatalf("ChangedSeeds() = %v, want 001_currencies.sql", changed)
+	}
+	// The flags seed would fail if it ran again.
+	if _, err := db.Exec(`INSERT INTO flags VALUES ('beta')`); err != nil {
+		t.Fatal(err)
+	}
+	content = "-- +goose Up\nINSERT INTO flags VALUES ('beta');\n"
+	if err := os.WriteFile(filepath.Join(dir, "002_flags.sql"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplySeeds(ctx, db, database.DialectSQLite3, dir, changed); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM currencies`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("currencies = %d rows, want 2", count)
+	}
+	if _, err := db.Exec(`SELECT version_id FROM goose_db_version`); err == nil {
+		t.Error("seeds created a version table")
+	}
+}
+
+func TestApplySeedsFailure(t *testing.T) {
+	db := openTestDB(t)
+	dir := writeSQLMigrations(t, map[string]string{
+		"001_flags.sql":  "-- +goose Up\nCREATE TABLE flags (name TEXT PRIMARY KEY);\n",
+		"002_broken.sql": "-- +goose Up\nINSERT INTO missing VALUES (1);\n",
+	})
+	seeds, err := CollectSeeds(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ApplySeeds(context.Background(), db, database.DialectSQLite3, dir, seeds)
+	if err == nil || !strings.HasPrefix(err.Error(), "002_broken.sql: ") {
+		t.Errorf("ApplySeeds() error = %v, want the failed seed named", err)
+	}
+	if len(results) != 1 || results[0].Version != 1 {
+		t.Errorf("ApplySeeds() = %+v, want the seed which ran before the failure", results)
+	}
+}
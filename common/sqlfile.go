@@ -0,0 +1,225 @@
+package common
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mfridman/interpolate"
+)
+
+// annotations are the `-- +goose` annotations of the provider on top of the ones of goose, matched by their first word.
+// Any other annotation is rejected: a misspelled one, e.g. `destructive-okay`, would otherwise be silently ignored.
+var annotations = []string{DestructiveOKAnnotation, BulkUpsertAnnotation}
+
+// Statement is a single statement of an SQL migration.
+type Statement struct {
+	SQL string
+	// Line is the line of the migration file the statement starts at.
+	Line int
+	// PrefixLines is the number of lines of pragmas and declarations put before SQL
+	// when a statement mixing scheme and data changes is split.
+	PrefixLines int
+}
+
+// SQLMigration is an SQL migration file split into statements the same way goose does it.
+type SQLMigration struct {
+	Up            []Statement
+	Down          []Statement
+	NoTransaction bool
+	// Annotations are the `-- +goose` annotations of the provider, e.g. `destructive-ok`.
+	Annotations []string
+}
+
+// HasAnnotation reports whether the migration carries the `-- +goose <annotation>` line.
+func (m *SQLMigration) HasAnnotation(annotation string) bool {
+	for _, a := range m.Annotations {
+		if strings.EqualFold(a, annotation) {
+			return true
+		}
+	}
+	return false
+}
+
+// Statements returns the statements of the given direction.
+func (m *SQLMigration) Statements(up bool) []Statement {
+	if up {
+		return m.Up
+	}
+	return m.Down
+}
+
+// ParseSQLMigrationFile reads and parses the SQL migration file.
+func ParseSQLMigrationFile(source string) (*SQLMigration, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := ParseSQLMigration(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return m, nil
+}
+
+// ParseSQLMigration splits an SQL migration into its up and down statements. Between `-- +goose ENVSUB ON`
+// and `-- +goose ENVSUB OFF`, ${VAR} references are replaced with environment variables as goose does it.
+func ParseSQLMigration(r io.Reader) (*SQLMigration, error) {
+	const (
+		start = iota
+		up
+		down
+	)
+
+	var (
+		m         SQLMigration
+		section   = start
+		inBlock   bool
+		envsub    bool
+		buf       strings.Builder
+		startLine int
+		lineNo    int
+	)
+
+	store := func() {
+		stmt := Statement{SQL: strings.TrimSpace(buf.String()), Line: startLine}
+		buf.Reset()
+		if section == up {
+			m.Up = append(m.Up, stmt)
+		} else {
+			m.Down = append(m.Down, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNo++
+
+		if strings.HasPrefix(line, "--") {
+			switch strings.TrimSpace(strings.TrimPrefix(line, "--")) {
+			case "+goose Up":
+				if section != start {
+					return nil, fmt.Errorf("line %d: duplicate '-- +goose Up' annotation", lineNo)
+				}
+				section = up
+				continue
+			case "+goose Down":
+				if section != up || inBlock {
+					return nil, fmt.Errorf("line %d: '-- +goose Down' must follow '-- +goose Up'", lineNo)
+				}
+				if strings.TrimSpace(buf.String()) != "" {
+					return nil, fmt.Errorf("line %d: unfinished statement, missing semicolon?", startLine)
+				}
+				buf.Reset()
+				section = down
+				continue
+			case "+goose StatementBegin":
+				if section == start || inBlock {
+					return nil, fmt.Errorf("line %d: unexpected '-- +goose StatementBegin'", lineNo)
+				}
+				inBlock = true
+				continue
+			case "+goose StatementEnd":
+				if !inBlock {
+					return nil, fmt.Errorf("line %d: '-- +goose StatementEnd' without '-- +goose StatementBegin'", lineNo)
+				}
+				inBlock = false
+				store()
+				continue
+			case "+goose NO TRANSACTION":
+				m.NoTransaction = true
+				continue
+			case "+goose ENVSUB ON":
+				envsub = true
+				continue
+			case "+goose ENVSUB OFF":
+				envsub = false
+				continue
+			default:
+				if annotation, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(line, "--")), "+goose "); ok {
+					annotation = strings.TrimSpace(annotation)
+					if !knownAnnotation(annotation) {
+						return nil, fmt.Errorf("line %d: unknown annotation '-- +goose %s'", lineNo, annotation)
+					}
+					m.Annotations = append(m.Annotations, annotation)
+					continue
+				}
+			}
+		}
+
+		if envsub {
+			expanded, err := interpolate.Interpolate(environment{}, line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: variable substitution failed: %w", lineNo, err)
+			}
+			line = expanded
+		}
+
+		if buf.Len() == 0 {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+				continue
+			}
+			if section == start {
+				return nil, fmt.Errorf("line %d: migration must start with '-- +goose Up' annotation", lineNo)
+			}
+			startLine = lineNo
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+
+		if !inBlock && endsWithSemicolon(line) {
+			store()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if section == start {
+		return nil, errors.New("migration must start with '-- +goose Up' annotation")
+	}
+	if inBlock {
+		return nil, errors.New("missing '-- +goose StatementEnd' annotation")
+	}
+	if strings.TrimSpace(buf.String()) != "" {
+		return nil, fmt.Errorf("line %d: unfinished statement, missing semicolon?", startLine)
+	}
+	return &m, nil
+}
+
+func knownAnnotation(annotation string) bool {
+	name, _, _ := strings.Cut(annotation, " ")
+	for _, known := range annotations {
+		if strings.EqualFold(name, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// environment looks the variables of ENVSUB up in the environment of the process.
+type environment struct{}
+
+func (environment) Get(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// endsWithSemicolon reports whether the line ends a statement, ignoring a trailing `--` comment.
+func endsWithSemicolon(line string) bool {
+	prev := ""
+	for _, word := range strings.Fields(line) {
+		if strings.HasPrefix(word, "--") {
+			break
+		}
+		prev = word
+	}
+	return strings.HasSuffix(prev, ";")
+}
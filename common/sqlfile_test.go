@@ -0,0 +1,83 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSQLMigration(t *testing.T) {
+	src := `-- +goose Up
+-- orders keep the customer payments
+CREATE TABLE orders (
+    id Uint64,
+    PRIMARY KEY (id)
+);
+UPSERT INTO orders (id) VALUES (1); -- seed
+
+-- +goose StatementBegin
+DEFINE ACTION $a() AS
+    SELECT 1;
+END DEFINE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE orders;
+`
+	m, err := ParseSQLMigration(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseSQLMigration() error = %v", err)
+	}
+	wantUp := []int{3, 7, 10}
+	if len(m.Up) != len(wantUp) {
+		t.Fatalf("ParseSQLMigration() up = %+v, want %d statements", m.Up, len(wantUp))
+	}
+	for i, line := range wantUp {
+		if m.Up[i].Line != line {
+			t.Errorf("up[%d].Line = %d, want %d", i, m.Up[i].Line, line)
+		}
+	}
+	if !strings.HasSuffix(m.Up[2].SQL, "END DEFINE;") {
+		t.Errorf("up[2] = %q, want the whole StatementBegin block", m.Up[2].SQL)
+	}
+	if len(m.Down) != 1 || m.Down[0].SQL != "DROP TABLE orders;" || m.Down[0].Line != 16 {
+		t.Errorf("down = %+v, want DROP TABLE orders at line 16", m.Down)
+	}
+}
+
+func TestParseSQLMigrationErrors(t *testing.T) {
+	tests := map[string]string{
+		"no up":             "CREATE TABLE orders (id Uint64);\n",
+		"missing semicolon": "-- +goose Up\nCREATE TABLE orders (id Uint64)\n",
+		"unterminated":      "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n",
+		"unknown":           "-- +goose Up\n-- +goose destructive-okay\nDROP TABLE orders;\n",
+		"unset variable":    "-- +goose Up\n-- +goose ENVSUB ON\nSELECT '${GOOSE_TEST_UNSET?is required}';\n",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSQLMigration(strings.NewReader(src)); err == nil {
+				t.Errorf("ParseSQLMigration() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestParseSQLMigrationEnvsub(t *testing.T) {
+	t.Setenv("GOOSE_TEST_TABLE", "orders")
+	src := `-- +goose Up
+-- +goose destructive-ok
+-- +goose ENVSUB ON
+DROP TABLE ${GOOSE_TEST_TABLE};
+-- +goose ENVSUB OFF
+SELECT '${GOOSE_TEST_TABLE}';
+`
+	m, err := ParseSQLMigration(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseSQLMigration() error = %v", err)
+	}
+	if len(m.Up) != 2 || m.Up[0].SQL != "DROP TABLE orders;" || m.Up[1].SQL != "SELECT '${GOOSE_TEST_TABLE}';" {
+		t.Errorf("up = %+v, want the variable substituted between ENVSUB ON and OFF only", m.Up)
+	}
+	if !m.HasAnnotation(DestructiveOKAnnotation) {
+		t.Errorf("annotations = %v, want %s", m.Annotations, DestructiveOKAnnotation)
+	}
+}
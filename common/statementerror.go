@@ -0,0 +1,190 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Issue"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// issueHints explain the failures people run into most. The first hint whose pattern matches
+// an issue or whose status is the status of the operation is used.
+var issueHints = []struct {
+	pattern *regexp.Regexp
+	status  Ydb.StatusIds_StatusCode
+	hint    string
+}{
+	{
+		pattern: regexp.MustCompile(`(?i)path exist|already exists`),
+		status:  Ydb.StatusIds_ALREADY_EXISTS,
+		hint: "the object already exists. It may have been created by hand or by an earlier apply which failed " +
+			"before recording its version: drop it, or make the statement idempotent, e.g. CREATE TABLE IF NOT EXISTS.",
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)type mismatch|mismatch type|cannot (be )?convert|failed to convert|implicit cast`),
+		hint: "YQL doesn't convert between types implicitly. Cast the value, e.g. CAST($id AS Uint64), " +
+			"or use a typed literal such as 1ul or Utf8(\"text\").",
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)scheme error|cannot find table|does not exist|not found|unknown (table|column|index)`),
+		status:  Ydb.StatusIds_SCHEME_ERROR,
+		hint: "the statement refers to a table, column or index which doesn't exist or has another scheme. " +
+			"Check TablePathPrefix and that the migrations before this one are applied to the database.",
+	},
+}
+
+// StatementIssue is an issue YDB reported for a statement, located in the migration file.
+type StatementIssue struct {
+	// Line is the line of the migration file, or the line the statement starts at if YDB gave no position.
+	Line int
+	// Column is 0 if YDB gave no position.
+	Column  int
+	Code    uint32
+	Message string
+}
+
+// StatementError is a failed statement of a migration. YDB counts the positions of its issues from the start
+// of the statement; they are mapped to the lines of the migration file.
+type StatementError struct {
+	Statement Statement
+	Issues    []StatementIssue
+	Hint      string
+	Err       error
+}
+
+// newStatementError wraps the error a statement failed with.
+func newStatementError(statement Statement, err error) *StatementError {
+	e := &StatementError{Statement: statement, Err: err}
+	messages := []string{err.Error()}
+
+	var reported interface {
+		Issues() []*Ydb_Issue.IssueMessage
+	}
+	if errors.As(err, &reported) {
+		for _, issue := range statementIssues(reported.Issues()) {
+			e.Issues = append(e.Issues, e.locate(issue))
+			messages = append(messages, issue.GetMessage())
+		}
+	}
+
+	for _, h := range issueHints {
+		if h.status != Ydb.StatusIds_STATUS_CODE_UNSPECIFIED && ydb.IsOperationError(err, h.status) {
+			e.Hint = h.hint
+			break
+		}
+		if h.pattern.MatchString(strings.Join(messages, "\n")) {
+			e.Hint = h.hint
+			break
+		}
+	}
+	return e
+}
+
+// statementIssues returns the innermost errors of the issue tree, which name the actual problem,
+// with the position of the closest issue around them that has one.
+func statementIssues(issues []*Ydb_Issue.IssueMessage) []*Ydb_Issue.IssueMessage {
+	var leaves, warnings []*Ydb_Issue.IssueMessage
+	var collect func(issues []*Ydb_Issue.IssueMessage, position *Ydb_Issue.IssueMessage_Position)
+	collect = func(issues []*Ydb_Issue.IssueMessage, position *Ydb_Issue.IssueMessage_Position) {
+		for _, issue := range issues {
+			if issue.GetPosition().GetRow() > 0 {
+				position = issue.GetPosition()
+			}
+			if len(issue.GetIssues()) > 0 {
+				collect(issue.GetIssues(), position)
+				continue
+			}
+			leaf := &Ydb_Issue.IssueMessage{
+				Position:  position,
+				Message:   issue.GetMessage(),
+				IssueCode: issue.GetIssueCode(),
+				Severity:  issue.GetSeverity(),
+			}
+			// Severity 0 is fatal, 1 is an error.
+			if leaf.Severity <= 1 {
+				leaves = append(leaves, leaf)
+			} else {
+				warnings = append(warnings, leaf)
+			}
+		}
+	}
+	collect(issues, nil)
+	if len(leaves) == 0 {
+		return warnings
+	}
+	return leaves
+}
+
+// locate maps the position of an issue, counted from the start of the statement, to the migration file.
+func (e *StatementError) locate(issue *Ydb_Issue.IssueMessage) StatementIssue {
+	located := StatementIssue{Line: e.Statement.Line, Code: issue.GetIssueCode(), Message: issue.GetMessage()}
+	// Rows before the statement itself are pragmas and declarations repeated from elsewhere in the file.
+	if row := int(issue.GetPosition().GetRow()) - e.Statement.PrefixLines; row > 0 {
+		located.Line = e.Statement.Line + row - 1
+		located.Column = int(issue.GetPosition().GetColumn())
+	}
+	return located
+}
+
+func (e *StatementError) Error() string {
+	var b strings.Builder
+	line := e.Statement.Line
+	if len(e.Issues) == 0 {
+		fmt.Fprintf(&b, "line %d: %v", e.Statement.Line, e.Err)
+	} else {
+		line = e.Issues[0].Line
+		for i, issue := range e.Issues {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "line %d", issue.Line)
+			if issue.Column > 0 {
+				fmt.Fprintf(&b, ", column %d", issue.Column)
+			}
+			fmt.Fprintf(&b, ": %s", issue.Message)
+			if issue.Code != 0 {
+				fmt.Fprintf(&b, " (code %d)", issue.Code)
+			}
+		}
+	}
+	if snippet := e.snippet(line); snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(snippet)
+	}
+	if e.Hint != "" {
+		b.WriteString("\nhint: ")
+		b.WriteString(e.Hint)
+	}
+	return b.String()
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// snippet returns the line of the statement and the lines around it, marking the line itself.
+func (e *StatementError) snippet(line int) string {
+	lines := strings.Split(e.Statement.SQL, "\n")
+	if e.Statement.PrefixLines < len(lines) {
+		lines = lines[e.Statement.PrefixLines:]
+	}
+	at := line - e.Statement.Line
+	if at < 0 || at >= len(lines) {
+		return ""
+	}
+	first, last := max(at-1, 0), min(at+1, len(lines)-1)
+	width := len(fmt.Sprint(e.Statement.Line + last))
+	snippet := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == at {
+			marker = ">"
+		}
+		snippet = append(snippet, strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, e.Statement.Line+i, lines[i]), " \t"))
+	}
+	return strings.Join(snippet, "\n")
+}
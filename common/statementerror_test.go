@@ -0,0 +1,79 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Issue"
+)
+
+type issuesError []*Ydb_Issue.IssueMessage
+
+func (e issuesError) Error() string {
+	return "operation/GENERIC_ERROR"
+}
+
+func (e issuesError) Issues() []*Ydb_Issue.IssueMessage {
+	return e
+}
+
+func TestStatementError(t *testing.T) {
+	statement := Statement{
+		SQL:         "PRAGMA TablePathPrefix('/local/shop');\nUPSERT INTO orders (id, status)\nVALUES\n    (1, 42);",
+		Line:        10,
+		PrefixLines: 1,
+	}
+	err := newStatementError(statement, issuesError{{
+		Message: "Type annotation",
+		Issues: []*Ydb_Issue.IssueMessage{{
+			Position: &Ydb_Issue.IssueMessage_Position{Row: 4, Column: 5},
+			Message:  "At function: KiWriteTable!",
+			Issues: []*Ydb_Issue.IssueMessage{
+				{Message: "Failed to convert type: Struct<'id':Int32,'status':Int32> to Struct<'id':Uint64?,'status':Utf8?>", IssueCode: 1030, Severity: 1},
+				{Message: "Unused pragma", Severity: 2},
+			},
+		}},
+	}})
+
+	want := "line 12, column 5: Failed to convert type: Struct<'id':Int32,'status':Int32> to Struct<'id':Uint64?,'status':Utf8?> (code 1030)\n" +
+		"  11 | VALUES\n" +
+		"> 12 |     (1, 42);\n" +
+		"hint: YQL doesn't convert"
+	if got := err.Error(); !strings.HasPrefix(got, want) {
+		t.Errorf("Error() = %q, want it to start with %q", got, want)
+	}
+	var issues issuesError
+	if !errors.As(err, &issues) {
+		t.Error("StatementError doesn't unwrap to the error of the driver")
+	}
+
+	t.Run("position in the prefix", func(t *testing.T) {
+		err := newStatementError(statement, issuesError{{
+			Position: &Ydb_Issue.IssueMessage_Position{Row: 1, Column: 8},
+			Message:  "Unknown pragma: TablePathPrefix",
+		}})
+		if got := err.Issues[0]; got.Line != 10 || got.Column != 0 {
+			t.Errorf("issue located at line %d, column %d, want the start of the statement", got.Line, got.Column)
+		}
+	})
+
+	t.Run("path exists", func(t *testing.T) {
+		err := newStatementError(Statement{SQL: "CREATE TABLE orders (id Uint64, PRIMARY KEY (id));", Line: 3}, issuesError{{
+			Message: "Check failed: path: '/local/orders', error: path exist, request accepts it",
+		}})
+		want := "line 3: Check failed: path: '/local/orders', error: path exist, request accepts it\n" +
+			"> 3 | CREATE TABLE orders (id Uint64, PRIMARY KEY (id));\n" +
+			"hint: the object already exists."
+		if got := err.Error(); !strings.HasPrefix(got, want) {
+			t.Errorf("Error() = %q, want it to start with %q", got, want)
+		}
+	})
+
+	t.Run("without issues", func(t *testing.T) {
+		err := newStatementError(Statement{SQL: "DROP TABLE payments;", Line: 7}, errors.New("no such table: payments"))
+		if got, want := err.Error(), "line 7: no such table: payments\n> 7 | DROP TABLE payments;"; got != want {
+			t.Errorf("Error() = %q, want %q", got, want)
+		}
+	})
+}
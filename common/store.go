@@ -0,0 +1,210 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// Store is the goose store of the version table extended with the columns
+// applied_by, checksum, duration_ms, file_name and terraform_run_id.
+//
+// Unlike the goose functions it doesn't rely on the scripting mode of the YDB driver: the version table
+// is created by a scheme query and versions are written in real table service transactions.
+type Store struct {
+	database.Store
+	// AppliedBy is recorded as applied_by, e.g. the subject of the provider credentials.
+	AppliedBy string
+	dialect   database.Dialect
+}
+
+// NewStore returns the store of the version table. The provider itself always uses the YDB dialect,
+// which is the default; tests run against SQLite.
+func NewStore(dialect database.Dialect, table string, appliedBy string) (*Store, error) {
+	if dialect == "" {
+		dialect = database.DialectYdB
+	}
+	if table == "" {
+		table = DefaultMigrationTable
+	}
+	store, err := database.NewStore(dialect, table)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Store: store, AppliedBy: appliedBy, dialect: dialect}, nil
+}
+
+// CreateVersionTable creates the version table with the history columns.
+func (s *Store) CreateVersionTable(ctx context.Context, db database.DBTxConn) error {
+	if err := s.Store.CreateVersionTable(withSchemeMode(ctx), db); err != nil {
+		return err
+	}
+	return s.addColumns(ctx, db)
+}
+
+// DropVersionTable drops the version table.
+func (s *Store) DropVersionTable(ctx context.Context, db database.DBTxConn) error {
+	if _, err := db.ExecContext(withSchemeMode(ctx), fmt.Sprintf(`DROP TABLE %s`, s.Tablename())); err != nil {
+		return fmt.Errorf("failed to drop version table %q: %w", s.Tablename(), err)
+	}
+	return nil
+}
+
+// Version returns the current version of the database: the highest version whose latest record
+// isn't a rollback. Versions recorded in one transaction, e.g. by Baseline, may share a timestamp,
+// so the order of the records doesn't decide it.
+func (s *Store) Version(ctx context.Context, db database.DBTxConn) (int64, error) {
+	migrations, err := s.ListMigrations(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	current, _, found := appliedVersions(migrations)
+	if !found {
+		return 0, goose.ErrNoNextVersion
+	}
+	return current, nil
+}
+
+// AppliedVersions returns the current version and the applied versions in ascending order without changing
+// the database. A database without the version table has nothing applied.
+func (s *Store) AppliedVersions(ctx context.Context, db *sql.DB) (int64, []int64, error) {
+	exists, err := s.TableExists(ctx, db)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !exists {
+		tflog.Info(ctx, fmt.Sprintf("goose: version table %s doesn't exist", s.Tablename()))
+		return 0, nil, nil
+	}
+	migrations, err := s.ListMigrations(ctx, db)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read version table %q: %w", s.Tablename(), err)
+	}
+	current, applied, _ := appliedVersions(migrations)
+	return current, applied, nil
+}
+
+// TableExists reports whether the version table exists. Unlike HasVersionTable it tells
+// a missing table from a failure to look it up, which is returned.
+func (s *Store) TableExists(ctx context.Context, db *sql.DB) (bool, error) {
+	var (
+		count int
+		err   error
+	)
+	switch s.dialect {
+	case database.DialectYdB:
+		return s.ydbTableExists(ctx, db)
+	case database.DialectSQLite3:
+		err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, s.Tablename()).Scan(&count)
+	default:
+		err = db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM information_schema.tables WHERE table_name = %s`, s.placeholder(1)), s.Tablename()).Scan(&count)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up version table %q: %w", s.Tablename(), err)
+	}
+	return count > 0, nil
+}
+
+func (s *Store) ydbTableExists(ctx context.Context, db *sql.DB) (bool, error) {
+	driver, err := ydb.Unwrap(db)
+	if err != nil {
+		return false, err
+	}
+	_, err = driver.Scheme().DescribePath(ctx, path.Join(driver.Name(), s.Tablename()))
+	switch {
+	case err == nil:
+		return true, nil
+	case ydb.IsOperationErrorSchemeError(err), ydb.IsOperationErrorNotFoundError(err):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to look up version table %q: %w", s.Tablename(), err)
+	}
+}
+
+// appliedVersions returns the highest applied version and the applied versions above 0 in ascending order.
+// A version is applied if its latest record, the first one listed, isn't a rollback.
+// It reports false if no version, not even 0, is applied.
+func appliedVersions(migrations []*database.ListMigrationsResult) (int64, []int64, bool) {
+	var (
+		current int64
+		applied []int64
+		found   bool
+	)
+	seen := make(map[int64]bool)
+	for _, m := range migrations {
+		if seen[m.Version] {
+			continue
+		}
+		seen[m.Version] = true
+		if !m.IsApplied {
+			continue
+		}
+		if !found || m.Version > current {
+			current, found = m.Version, true
+		}
+		if m.Version > 0 {
+			applied = append(applied, m.Version)
+		}
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i] < applied[j] })
+	return current, applied, found
+}
+
+// EnsureVersion returns the current version of the database. A missing version table
+// is created and the version 0 is recorded in it.
+func (s *Store) EnsureVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	version, err := s.Version(ctx, db)
+	if err == nil {
+		return version, nil
+	}
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		// The version table exists, but has nothing applied.
+		return 0, nil
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("goose: creating version table %s", s.Tablename()))
+	if err := s.CreateVersionTable(ctx, db); err != nil {
+		return 0, err
+	}
+	return 0, inTx(ctx, db, func(tx *sql.Tx) error {
+		return s.Insert(ctx, tx, database.InsertRequest{Version: 0})
+	})
+}
+
+// setVersion records the migration version as applied or removes it after a rollback.
+func (s *Store) setVersion(ctx context.Context, db database.DBTxConn, version int64, up bool) error {
+	if up {
+		if err := s.Insert(ctx, db, database.InsertRequest{Version: version}); err != nil {
+			return fmt.Errorf("failed to insert new goose version: %w", err)
+		}
+		return nil
+	}
+	if err := s.Delete(ctx, db, version); err != nil {
+		return fmt.Errorf("failed to delete goose version: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction and commits it unless fn fails.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
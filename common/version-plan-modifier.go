@@ -4,11 +4,12 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/pressly/goose/v3"
 )
 
@@ -25,58 +26,31 @@ func (v versionPlanModifier) MarkdownDescription(_ context.Context) string {
 }
 
 func (m versionPlanModifier) PlanModifyInt64(ctx context.Context, req planmodifier.Int64Request, resp *planmodifier.Int64Response) {
-
-	var migrationsDir string
-	var target *int64
-
-	diags := req.Plan.GetAttribute(ctx, path.Root("migrations_dir"), &migrationsDir)
+	migrationsDirs, diags := planMigrationsDirs(ctx, req.Plan, req.State)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	diags = req.Plan.GetAttribute(ctx, path.Root("target_version"), &target)
 
-	if resp.Diagnostics.HasError() {
+	migrations, err := CollectMigrations(migrationsDirs)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect migrations", err.Error())
 		return
 	}
-	if migrationsDir == "" {
-		tflog.Debug(ctx, "no migrations_dir in plan")
-		req.State.GetAttribute(ctx, path.Root("migrations_dir"), &migrationsDir)
-	}
 
-	if migrationsDir == "" {
-		resp.Diagnostics.AddError("migrations_dir", "migrations_dir is required")
+	version, ok, diags := plannedVersion(ctx, req.Plan, req.State, migrations)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || !ok {
 		return
 	}
 
-	migrations, err := goose.CollectMigrations(
-		migrationsDir,
-		0,
-		maxVersion,
-	)
-	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("Failed to collect migrations: %s %s", migrationsDir, err.Error()))
+	// The migrations the version is reached by are checked by the resource, which knows the version
+	// of the database, see CheckPlannedMigrations.
+	resp.Diagnostics.Append(checkDrift(ctx, req.State, migrations)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	if len(migrations) > 0 {
-		maxKnownVersion := migrations[len(migrations)-1].Version
-		if target == nil {
-			resp.PlanValue = types.Int64Value(maxKnownVersion)
-			return
-		}
-		if *target < 0 {
-			resp.Diagnostics.AddError("target_version", "target_version is less than 0")
-			return
-		}
-		if *target > maxKnownVersion {
-			resp.Diagnostics.AddError("target_version", "target_version is greater than the latest migration")
-			return
-		} else {
-			resp.PlanValue = types.Int64Value(*target)
-		}
-	}
-
+	resp.PlanValue = types.Int64Value(version)
 }
 
 func VersionPlanModifier() planmodifier.Int64 {
@@ -94,51 +68,172 @@ func (m migrationsPlanModifier) MarkdownDescription(_ context.Context) string {
 }
 
 func (m migrationsPlanModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
-	var migrationsDir string
-	var target *int64
-
-	diags := req.Plan.GetAttribute(ctx, path.Root("migrations_dir"), &migrationsDir)
+	migrationsDirs, diags := planMigrationsDirs(ctx, req.Plan, req.State)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	diags = req.Plan.GetAttribute(ctx, path.Root("target_version"), &target)
 
-	if resp.Diagnostics.HasError() {
+	migrations, err := CollectMigrations(migrationsDirs)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect migrations", err.Error())
 		return
 	}
-	if migrationsDir == "" {
-		tflog.Debug(ctx, "no migrations_dir in plan")
-		req.State.GetAttribute(ctx, path.Root("migrations_dir"), &migrationsDir)
-	}
 
-	if migrationsDir == "" {
-		resp.Diagnostics.AddError("migrations_dir", "migrations_dir is required")
+	version, _, diags := plannedVersion(ctx, req.Plan, req.State, migrations)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	migrations, err := goose.CollectMigrations(
-		migrationsDir,
-		0,
-		maxVersion,
-	)
-	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("Failed to collect migrations: %s %s", migrationsDir, err.Error()))
-		return
-	}
+	val, diags := MigrationList(ctx, migrations, version)
+	resp.Diagnostics.Append(diags...)
+	resp.PlanValue = val
+}
 
+// MigrationList returns the sources of the migrations at or below version.
+func MigrationList(ctx context.Context, migrations goose.Migrations, version int64) (types.List, diag.Diagnostics) {
 	var migrationVersions []string
 	for _, migration := range migrations {
-		if target != nil && migration.Version > *target {
+		if migration.Version > version {
 			continue
 		}
 		migrationVersions = append(migrationVersions, migration.String())
 	}
-	val, diag := types.ListValueFrom(ctx, types.StringType, migrationVersions)
-	resp.Diagnostics.Append(diag...)
-	resp.PlanValue = val
+	return types.ListValueFrom(ctx, types.StringType, migrationVersions)
 }
 
 func MigrationsPlanModifier() planmodifier.List {
 	return migrationsPlanModifier{}
 }
+
+// plannedVersion returns the version the database is planned to be at after apply, counting max_steps
+// from the version in the state, or from baseline_version on create. It reports false if there are
+// no migrations to plan for.
+func plannedVersion(ctx context.Context, plan attributeGetter, state attributeGetter, migrations goose.Migrations) (int64, bool, diag.Diagnostics) {
+	var current types.Int64
+	diags := state.GetAttribute(ctx, path.Root("version"), &current)
+	if current.IsNull() {
+		diags.Append(plan.GetAttribute(ctx, path.Root("baseline_version"), &current)...)
+	}
+	if diags.HasError() {
+		return 0, false, diags
+	}
+	version, ok, d := PlannedVersion(ctx, plan, migrations, current.ValueInt64())
+	diags.Append(d...)
+	return version, ok, diags
+}
+
+// PlannedVersion returns the version the database at current is planned to be at after apply:
+// target_version or the latest migration, limited by max_steps.
+// It reports false if there are no migrations to plan for.
+func PlannedVersion(ctx context.Context, plan attributeGetter, migrations goose.Migrations, current int64) (int64, bool, diag.Diagnostics) {
+	var target, maxSteps *int64
+
+	diags := plan.GetAttribute(ctx, path.Root("target_version"), &target)
+	diags.Append(plan.GetAttribute(ctx, path.Root("max_steps"), &maxSteps)...)
+	if diags.HasError() || len(migrations) == 0 {
+		return 0, false, diags
+	}
+
+	version, d := TargetVersion(migrations, target)
+	diags.Append(d...)
+	if diags.HasError() {
+		return 0, false, diags
+	}
+
+	if maxSteps != nil {
+		version = StepTarget(migrations, current, version, *maxSteps)
+	}
+	return version, true, diags
+}
+
+// CheckPlannedMigrations fails the plan if the migrations run from current to version contain destructive
+// statements or roll back a migration without Down statements and the resource doesn't allow it.
+// It warns about the migrations max_steps leaves for later applies.
+func CheckPlannedMigrations(ctx context.Context, plan attributeGetter, migrations goose.Migrations, current int64, version int64) diag.Diagnostics {
+	diags := checkDestructive(ctx, plan, migrations, current, version)
+	diags.Append(checkIrreversible(ctx, plan, migrations, current, version)...)
+	if diags.HasError() {
+		return diags
+	}
+	diags.Append(warnDeferred(ctx, plan, migrations, version)...)
+	return diags
+}
+
+// warnDeferred warns about the migrations which max_steps leaves for later applies, listing them in the order
+// they will run, so the plan shows how far the rollout is from target_version.
+func warnDeferred(ctx context.Context, plan attributeGetter, migrations goose.Migrations, version int64) diag.Diagnostics {
+	var target, maxSteps *int64
+
+	diags := plan.GetAttribute(ctx, path.Root("target_version"), &target)
+	diags.Append(plan.GetAttribute(ctx, path.Root("max_steps"), &maxSteps)...)
+	if diags.HasError() || maxSteps == nil {
+		return diags
+	}
+	final, d := TargetVersion(migrations, target)
+	if d.HasError() {
+		return diags
+	}
+
+	deferred, _ := PendingMigrations(migrations, version, final)
+	if len(deferred) == 0 {
+		return diags
+	}
+	names := make([]string, 0, len(deferred))
+	for _, migration := range deferred {
+		names = append(names, migration.String())
+	}
+	diags.AddAttributeWarning(
+		path.Root("max_steps"),
+		"Migrations deferred by max_steps",
+		fmt.Sprintf("max_steps = %d stops this apply at version %d. %d migrations are left for the next applies:\n%s",
+			*maxSteps, version, len(deferred), strings.Join(names, "\n")),
+	)
+	return diags
+}
+
+// TargetVersion returns target_version, or the latest migration if it is not set.
+func TargetVersion(migrations goose.Migrations, target *int64) (int64, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var maxKnownVersion int64
+	if len(migrations) > 0 {
+		maxKnownVersion = migrations[len(migrations)-1].Version
+	}
+	if target == nil {
+		return maxKnownVersion, diags
+	}
+	if *target < 0 {
+		diags.AddError("target_version", "target_version is less than 0")
+	} else if *target > maxKnownVersion {
+		diags.AddError("target_version", "target_version is greater than the latest migration")
+	}
+	return *target, diags
+}
+
+// StepTarget returns the version the database is at after migrating from current towards target
+// running at most maxSteps migrations.
+func StepTarget(migrations goose.Migrations, current int64, target int64, maxSteps int64) int64 {
+	var steps []int64
+	if target > current {
+		for _, migration := range migrations {
+			if migration.Version > current && migration.Version <= target {
+				steps = append(steps, migration.Version)
+			}
+		}
+		if int64(len(steps)) > maxSteps {
+			return steps[maxSteps-1]
+		}
+		return target
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version <= current {
+			steps = append(steps, migrations[i].Version)
+		}
+	}
+	if int64(len(steps)) > maxSteps && steps[maxSteps] > target {
+		return steps[maxSteps]
+	}
+	return target
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
+)
+
+// fakeAttributes is a plan or a state of the given attributes; the others are null.
+type fakeAttributes map[string]interface{}
+
+func (f fakeAttributes) GetAttribute(_ context.Context, p path.Path, target interface{}) diag.Diagnostics {
+	if value, ok := f[p.String()]; ok {
+		reflect.ValueOf(target).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func TestStepTarget(t *testing.T) {
+	migrations := goose.Migrations{
+		{Version: 1}, {Version: 2}, {Version: 3}, {Version: 5}, {Version: 8},
+	}
+	tests := []struct {
+		name     string
+		current  int64
+		target   int64
+		maxSteps int64
+		want     int64
+	}{
+		{name: "up limited", current: 0, target: 8, maxSteps: 2, want: 2},
+		{name: "up continues", current: 2, target: 8, maxSteps: 2, want: 5},
+		{name: "up within limit", current: 3, target: 8, maxSteps: 2, want: 8},
+		{name: "down limited", current: 8, target: 1, maxSteps: 2, want: 3},
+		{name: "down within limit", current: 3, target: 1, maxSteps: 2, want: 1},
+		{name: "down to zero", current: 2, target: 0, maxSteps: 2, want: 0},
+		{name: "no change", current: 5, target: 5, maxSteps: 1, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StepTarget(migrations, tt.current, tt.target, tt.maxSteps); got != tt.want {
+				t.Errorf("StepTarget() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlannedVersionFromBaseline(t *testing.T) {
+	ctx := context.Background()
+	migrations := goose.Migrations{
+		{Version: 1}, {Version: 2}, {Version: 3}, {Version: 5}, {Version: 8},
+	}
+	maxSteps := int64(2)
+
+	// On create the migrations up to the baseline are only recorded, so max_steps counts from it.
+	plan := fakeAttributes{"max_steps": &maxSteps, "baseline_version": types.Int64Value(2)}
+	if version, ok, diags := plannedVersion(ctx, plan, fakeAttributes{}, migrations); !ok || diags.HasError() || version != 5 {
+		t.Errorf("plannedVersion() on create = %d, %v, %v, want 5", version, ok, diags)
+	}
+	state := fakeAttributes{"version": types.Int64Value(3)}
+	if version, ok, diags := plannedVersion(ctx, plan, state, migrations); !ok || diags.HasError() || version != 8 {
+		t.Errorf("plannedVersion() on update = %d, %v, %v, want 8", version, ok, diags)
+	}
+	if version, _, _ := plannedVersion(ctx, fakeAttributes{"max_steps": &maxSteps}, fakeAttributes{}, migrations); version != 2 {
+		t.Errorf("plannedVersion() without a baseline = %d, want 2", version)
+	}
+}
+
+func TestWarnDeferred(t *testing.T) {
+	migrations := goose.Migrations{
+		{Version: 1, Source: "migrations/001_a.sql"},
+		{Version: 2, Source: "migrations/002_b.sql"},
+		{Version: 3, Source: "migrations/003_c.sql"},
+	}
+	maxSteps := int64(1)
+
+	diags := warnDeferred(context.Background(), fakeAttributes{"max_steps": &maxSteps}, migrations, 1)
+	if len(diags) != 1 {
+		t.Fatalf("warnDeferred() = %v, want one warning", diags)
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, "002_b.sql") || !strings.Contains(detail, "003_c.sql") {
+		t.Errorf("warning = %q, want the migrations after version 1", detail)
+	}
+
+	if diags := warnDeferred(context.Background(), fakeAttributes{"max_steps": &maxSteps}, migrations, 3); len(diags) != 0 {
+		t.Errorf("warnDeferred() at the target = %v, want none", diags)
+	}
+	if diags := warnDeferred(context.Background(), fakeAttributes{}, migrations, 1); len(diags) != 0 {
+		t.Errorf("warnDeferred() without max_steps = %v, want none", diags)
+	}
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	schemeStatement = regexp.MustCompile(`(?is)^\s*(CREATE|ALTER|DROP|GRANT|REVOKE)\s`)
+	pragmaStatement = regexp.MustCompile(`(?is)^\s*PRAGMA\s`)
+	// declareStatement matches the parameter declarations and named expressions data queries refer to.
+	declareStatement = regexp.MustCompile(`(?is)^\s*(DECLARE\s|\$\w+\s*=)`)
+	blockComment     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+)
+
+// splitYQL splits the text into its statements at the semicolons outside of string literals,
+// quoted identifiers and comments. Each statement is returned with its offset in the text.
+func splitYQL(text string) (statements []string, offsets []int) {
+	start := 0
+	add := func(end int) {
+		part := text[start:end]
+		if stripComments(part) != "" {
+			trimmed := strings.TrimLeft(part, " \t\r\n")
+			statements = append(statements, strings.TrimSpace(trimmed))
+			offsets = append(offsets, start+len(part)-len(trimmed))
+		}
+		start = end
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '-' && strings.HasPrefix(text[i:], "--"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				i = len(text)
+			} else {
+				i += end + 3
+			}
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(text) && text[i] != c; i++ {
+				if text[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case c == ';':
+			add(i + 1)
+		}
+	}
+	add(len(text))
+	return statements, offsets
+}
+
+func stripComments(text string) string {
+	return strings.TrimSpace(lineComment.ReplaceAllString(blockComment.ReplaceAllString(text, ""), ""))
+}
+
+// IsSchemeStatement reports whether the statement changes the scheme, e.g. CREATE TABLE or ALTER TABLE,
+// rather than reading or writing data. Leading pragmas are skipped.
+func IsSchemeStatement(statement string) bool {
+	return schemeStatement.MatchString(skipPragmas(statement))
+}
+
+// skipPragmas returns the first part of the statement which isn't a pragma, without comments.
+func skipPragmas(statement string) string {
+	parts, _ := splitYQL(statement)
+	for _, part := range parts {
+		if part := stripComments(part); !pragmaStatement.MatchString(part) {
+			return part
+		}
+	}
+	return ""
+}
+
+// SplitStatements splits the statements which mix scheme changes and data queries,
+// e.g. a `StatementBegin`/`StatementEnd` block creating a table and filling it, because YDB can't run them
+// in one request. The pragmas of a split statement are repeated in every part, its declarations and named
+// expressions in every data part. Other statements are kept as they are.
+func SplitStatements(statements []Statement) []Statement {
+	var split []Statement
+	for _, statement := range statements {
+		parts, offsets := splitYQL(statement.SQL)
+
+		var pragmas, declarations []string
+		scheme, data := false, false
+		for _, part := range parts {
+			text := stripComments(part)
+			switch {
+			case pragmaStatement.MatchString(text):
+				pragmas = append(pragmas, part)
+			case declareStatement.MatchString(text):
+				declarations = append(declarations, part)
+			case schemeStatement.MatchString(text):
+				scheme = true
+			default:
+				data = true
+			}
+		}
+		if !scheme || !data {
+			split = append(split, statement)
+			continue
+		}
+
+		for i, part := range parts {
+			text := stripComments(part)
+			if pragmaStatement.MatchString(text) || declareStatement.MatchString(text) {
+				continue
+			}
+			prefix := pragmas
+			if !schemeStatement.MatchString(text) {
+				prefix = append(append([]string(nil), pragmas...), declarations...)
+			}
+			prefixLines := 0
+			for _, p := range prefix {
+				prefixLines += strings.Count(p, "\n") + 1
+			}
+			split = append(split, Statement{
+				SQL:         strings.Join(append(append([]string(nil), prefix...), part), "\n"),
+				Line:        statement.Line + strings.Count(statement.SQL[:offsets[i]], "\n"),
+				PrefixLines: prefixLines,
+			})
+		}
+	}
+	return split
+}
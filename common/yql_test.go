@@ -0,0 +1,42 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitYQL(t *testing.T) {
+	text := "UPSERT INTO t (s) VALUES ('a;b'); -- c;d\n/* e; */ SELECT `f;g` FROM t;\n-- trailing"
+	statements, offsets := splitYQL(text)
+	want := []string{"UPSERT INTO t (s) VALUES ('a;b');", "-- c;d\n/* e; */ SELECT `f;g` FROM t;"}
+	if !reflect.DeepEqual(statements, want) {
+		t.Fatalf("splitYQL() = %q, want %q", statements, want)
+	}
+	if !reflect.DeepEqual(offsets, []int{0, 34}) {
+		t.Errorf("splitYQL() offsets = %v, want [0 34]", offsets)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := []Statement{
+		{SQL: "PRAGMA TablePathPrefix('/db');\nDECLARE $id AS Uint64;\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));\nUPSERT INTO orders (id) VALUES ($id);", Line: 2},
+		{SQL: "DECLARE $id AS Uint64;\nUPSERT INTO orders (id) VALUES ($id);", Line: 8},
+	}
+	want := []Statement{
+		{SQL: "PRAGMA TablePathPrefix('/db');\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));", Line: 4, PrefixLines: 1},
+		{SQL: "PRAGMA TablePathPrefix('/db');\nDECLARE $id AS Uint64;\nUPSERT INTO orders (id) VALUES ($id);", Line: 5, PrefixLines: 2},
+		statements[1],
+	}
+	if got := SplitStatements(statements); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsSchemeStatement(t *testing.T) {
+	if !IsSchemeStatement("PRAGMA TablePathPrefix('/db');\nCREATE TABLE orders (id Uint64, PRIMARY KEY (id));") {
+		t.Error("CREATE TABLE after a pragma is not a scheme statement")
+	}
+	if IsSchemeStatement("/* CREATE TABLE */ UPSERT INTO orders (id) VALUES (1);") {
+		t.Error("UPSERT is a scheme statement")
+	}
+}
@@ -0,0 +1,422 @@
+package goose_cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"terraform-provider-goose/common"
+	goose_provider "terraform-provider-goose/goose-provider"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tfsdklog"
+	"github.com/pressly/goose/v3"
+)
+
+const usage = `Usage: terraform-provider-goose <command> [flags]
+
+Runs the migrations of a YDB database the same way the goose_ydb_migration resource does.
+
+Commands:
+  status         Print the version of the database and the applied and pending migrations.
+  up             Apply the pending migrations up to -to, the latest by default.
+  down           Roll back the last migration, or down to -to.
+  redo           Roll back the migrations of the last -versions applied versions and apply them again.
+  create <name>  Create the next SQL migration file in the first -dir.
+
+Run a command with -h to list its flags.
+`
+
+var commands = map[string]bool{
+	"status": true,
+	"up":     true,
+	"down":   true,
+	"redo":   true,
+	"create": true,
+}
+
+// IsCommand reports whether the arguments of the binary start with a command rather than the plugin flags.
+func IsCommand(args []string) bool {
+	return len(args) > 0 && (commands[args[0]] || args[0] == "help")
+}
+
+// newConfig configures the provider credentials like the provider block does.
+var newConfig = func(ctx context.Context, state provider_config.State) (*provider_config.Config, error) {
+	config := &provider_config.Config{ProviderState: goose_provider.SetDefaults(state)}
+	if err := config.InitAndValidate(ctx, "", false); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+type options struct {
+	endpoint   string
+	database   string
+	databaseID string
+	tlsEnabled bool
+	dirs       []string
+	table      string
+	timeout    time.Duration
+	verbose    bool
+
+	to                    int64
+	versions              int64
+	allowDestructive      bool
+	allowIrreversibleSkip bool
+
+	provider provider_config.State
+}
+
+// Run runs the command of args, writing its output to stdout and the errors and logs to stderr.
+// It returns the exit code of the process.
+func Run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	if err := run(ctx, args[0], args[1:], stdout, stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func run(ctx context.Context, command string, args []string, stdout io.Writer, stderr io.Writer) error {
+	opts := options{to: -1}
+	flags := newFlagSet(command, &opts, stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(opts.dirs) == 0 {
+		return errors.New("-dir is required")
+	}
+
+	if command == "create" {
+		if flags.NArg() != 1 {
+			return errors.New("create takes the name of the migration, e.g. `create add_orders`")
+		}
+		file, err := createMigration(opts.dirs, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Created %s\n", file)
+		return nil
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("%s takes no arguments, got %q", command, flags.Args())
+	}
+
+	level := hclog.Warn
+	if opts.verbose {
+		level = hclog.Info
+	}
+	ctx = tfsdklog.NewRootProviderLogger(ctx, tfsdklog.WithLogName("goose"), tfsdklog.WithLevel(level), tfsdklog.WithoutLocation())
+
+	migrations, err := common.CollectMigrations(opts.dirs)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	return withDB(ctx, opts, func(ctx context.Context, config *provider_config.Config, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error {
+		if command == "status" {
+			// status only reads: a database without the version table has nothing applied.
+			current, applied, err := store.AppliedVersions(ctx, db)
+			if err != nil {
+				return fmt.Errorf("failed to get current migration version: %w", err)
+			}
+			printStatus(stdout, migrations, current, applied)
+			return nil
+		}
+
+		current, err := store.EnsureVersion(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to get current migration version: %w", err)
+		}
+
+		var (
+			action  string
+			target  int64
+			results []common.MigrationResult
+		)
+		started := time.Now()
+		switch command {
+		case "up":
+			action, target = "up", opts.to
+			if target < 0 {
+				target = latestVersion(migrations)
+			}
+			if target < current {
+				return fmt.Errorf("-to %d is below the current version %d, use down", target, current)
+			}
+			pending, _ := common.PendingMigrations(migrations, current, target)
+			if err := lint(opts, pending, true); err != nil {
+				return err
+			}
+			results, err = common.UpTo(ctx, db, store, migrations, target)
+		case "down":
+			action, target = "down", opts.to
+			if target < 0 {
+				target = previousVersion(migrations, current)
+			}
+			if target > current {
+				return fmt.Errorf("-to %d is above the current version %d, use up", target, current)
+			}
+			pending, _ := common.PendingMigrations(migrations, current, target)
+			if err := lint(opts, pending, false); err != nil {
+				return err
+			}
+			results, err = common.DownTo(ctx, db, store, migrations, target, opts.allowIrreversibleSkip)
+		case "redo":
+			action, target = "redo", current
+			_, applied, readErr := store.AppliedVersions(ctx, db)
+			if readErr != nil {
+				return fmt.Errorf("failed to get current migration version: %w", readErr)
+			}
+			redoMigrations, redoErr := common.RedoMigrations(migrations, applied, opts.versions)
+			if redoErr != nil {
+				return redoErr
+			}
+			if err := common.CheckRedoable(redoMigrations); err != nil {
+				return err
+			}
+			if err := lint(opts, redoMigrations, false); err != nil {
+				return err
+			}
+			if err := lint(opts, redoMigrations, true); err != nil {
+				return err
+			}
+			results, err = common.Redo(ctx, db, store, migrations, opts.versions)
+		}
+
+		printResults(stdout, results)
+		audit(ctx, stderr, config, params, action, current, target, started, results, err)
+		if err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
+		version, err := store.EnsureVersion(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to get current migration version: %w", err)
+		}
+		fmt.Fprintf(stdout, "Database is at version %d\n", version)
+		return nil
+	})
+}
+
+func newFlagSet(command string, opts *options, output io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Func("dir", "Directory of the migration files. Repeat it to merge several directories.", func(dir string) error {
+		opts.dirs = append(opts.dirs, dir)
+		return nil
+	})
+	if command == "create" {
+		return flags
+	}
+
+	flags.StringVar(&opts.endpoint, "endpoint", "", "YDB endpoint, e.g. ydb.serverless.yandexcloud.net:2135 "+
+		"or grpcs://ydb.serverless.yandexcloud.net:2135/?database=/ru-central1/b1g/etn.")
+	flags.StringVar(&opts.database, "database", "", "Path of the database, e.g. /ru-central1/b1g/etn.")
+	flags.StringVar(&opts.databaseID, "database-id", "", "Yandex Cloud ID of the database, instead of -endpoint and -database.")
+	flags.BoolVar(&opts.tlsEnabled, "tls", true, "Connect to the endpoint with TLS.")
+	flags.StringVar(&opts.table, "table", "", "Version table. Defaults to "+common.DefaultMigrationTable+".")
+	flags.DurationVar(&opts.timeout, "timeout", common.DefaultTimeout, "Timeout of the command.")
+	flags.BoolVar(&opts.verbose, "v", false, "Log the progress of the migrations to stderr.")
+
+	flags.Func("token", common.Descriptions["token"]+" Defaults to $YC_TOKEN.", stringFlag(&opts.provider.Token))
+	flags.Func("service-account-key-file", common.Descriptions["service_account_key_file"]+" Defaults to $YC_SERVICE_ACCOUNT_KEY_FILE.",
+		stringFlag(&opts.provider.ServiceAccountKeyFileOrContent))
+	flags.Func("profile", common.Descriptions["profile"]+" Defaults to $YC_PROFILE.", stringFlag(&opts.provider.Profile))
+	flags.Func("api-endpoint", common.Descriptions["endpoint"]+" Defaults to $YC_ENDPOINT.", stringFlag(&opts.provider.Endpoint))
+	flags.Func("audit-log", common.Descriptions["audit_log_path"], stringFlag(&opts.provider.AuditLogPath))
+
+	switch command {
+	case "up", "down":
+		flags.Int64Var(&opts.to, "to", -1, "Version to migrate to.")
+		flags.BoolVar(&opts.allowDestructive, "allow-destructive", false, "Run migrations with destructive statements.")
+	case "redo":
+		flags.Int64Var(&opts.versions, "versions", common.DefaultRedoVersions, "Number of the latest applied versions to redo.")
+		flags.BoolVar(&opts.allowDestructive, "allow-destructive", false, "Run migrations with destructive statements.")
+	}
+	if command == "down" {
+		flags.BoolVar(&opts.allowIrreversibleSkip, "allow-irreversible-skip", false,
+			"Record migrations without Down statements as rolled back without running anything.")
+	}
+	return flags
+}
+
+func stringFlag(target *types.String) func(string) error {
+	return func(value string) error {
+		*target = types.StringValue(value)
+		return nil
+	}
+}
+
+// withDB opens the database of the options and runs fn with its resolved connection details and the store
+// of its version table while holding the migration lock.
+func withDB(ctx context.Context, opts options, fn func(ctx context.Context, config *provider_config.Config, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	config, err := newConfig(ctx, opts.provider)
+	if err != nil {
+		return fmt.Errorf("failed to configure: %w", err)
+	}
+	params, err := connectionParams(ctx, config, opts)
+	if err != nil {
+		return err
+	}
+	ctx, db, err := config.OpenDB(ctx, params)
+	if err != nil {
+		return err
+	}
+	defer provider_config.CloseDB(ctx, db)
+
+	unlock := common.LockMigrations(opts.table)
+	defer unlock()
+
+	store, err := common.NewStore(config.Dialect, opts.table, config.Subject)
+	if err != nil {
+		return fmt.Errorf("failed to open version table: %w", err)
+	}
+	return fn(ctx, config, params, db, store)
+}
+
+func connectionParams(ctx context.Context, config *provider_config.Config, opts options) (provider_config.ConnectionParams, error) {
+	if opts.databaseID == "" {
+		switch {
+		case strings.Contains(opts.endpoint, "://"):
+			return provider_config.ParseDatabaseEndpoint(opts.endpoint)
+		case opts.endpoint == "" || opts.database == "":
+			return provider_config.ConnectionParams{}, errors.New("either -database-id or -endpoint and -database are required")
+		}
+	}
+	return provider_config.ConnectionParamsFrom(ctx, config, opts.databaseID, opts.endpoint, opts.database, &opts.tlsEnabled)
+}
+
+// lint fails like the plan of the resource does if the pending migrations contain destructive statements
+// or roll back migrations without Down statements, unless the options allow it.
+func lint(opts options, pending goose.Migrations, up bool) error {
+	if !opts.allowDestructive {
+		found, err := common.DestructiveStatements(pending, up)
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return fmt.Errorf("the migrations contain destructive statements:\n%s\n\n"+
+				"Pass -allow-destructive or annotate reviewed files with `-- +goose %s`",
+				strings.Join(found, "\n"), common.DestructiveOKAnnotation)
+		}
+	}
+	if !up && !opts.allowIrreversibleSkip {
+		found, err := common.IrreversibleMigrations(pending)
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			return fmt.Errorf("rolling back requires rolling back migrations without Down statements:\n%s\n\n"+
+				"Pass -allow-irreversible-skip to record them as rolled back without running anything",
+				strings.Join(found, "\n"))
+		}
+	}
+	return nil
+}
+
+func latestVersion(migrations goose.Migrations) int64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// previousVersion returns the version the database is at after rolling back the migration of current.
+func previousVersion(migrations goose.Migrations, current int64) int64 {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Version < current {
+			return migrations[i].Version
+		}
+	}
+	return 0
+}
+
+// printStatus lists the migrations as applied or pending. A migration older than the current version
+// which was never applied is pending: migrating up doesn't run it.
+func printStatus(w io.Writer, migrations goose.Migrations, current int64, applied []int64) {
+	isApplied := make(map[int64]bool, len(applied))
+	for _, version := range applied {
+		isApplied[version] = true
+	}
+	fmt.Fprintf(w, "Database is at version %d\n", current)
+	for _, migration := range migrations {
+		state := "pending"
+		if isApplied[migration.Version] {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", state, filepath.Base(migration.Source))
+	}
+}
+
+func printResults(w io.Writer, results []common.MigrationResult) {
+	for _, result := range results {
+		fmt.Fprintf(w, "  %-8s %s (%s)\n", result.Direction(), filepath.Base(result.Source), result.Duration.Round(time.Millisecond))
+	}
+}
+
+// audit appends the action to the audit log, if one is given, and reports a failure to write it to stderr.
+func audit(ctx context.Context, stderr io.Writer, config *provider_config.Config, params provider_config.ConnectionParams, action string, from int64, to int64, started time.Time, results []common.MigrationResult, actionErr error) {
+	record := provider_config.NewAuditRecord(action, params, from, to, started, results, actionErr)
+	if err := config.Audit(ctx, record); err != nil {
+		fmt.Fprintf(stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+const migrationTemplate = `-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+`
+
+// createMigration writes the SQL migration following the latest one of all the directories into the first one.
+// The version has as many digits as the latest one, 5 if there is none.
+func createMigration(dirs []string, name string) (string, error) {
+	name = strings.Join(strings.Fields(name), "_")
+	if name == "" {
+		return "", errors.New("the name of the migration is empty")
+	}
+	migrations, err := common.CollectMigrations(dirs)
+	if err != nil {
+		return "", fmt.Errorf("failed to collect migrations: %w", err)
+	}
+
+	digits := 5
+	if len(migrations) > 0 {
+		latest := filepath.Base(migrations[len(migrations)-1].Source)
+		digits = strings.Index(latest, "_")
+	}
+	file := filepath.Join(dirs[0], fmt.Sprintf("%0*d_%s.sql", digits, latestVersion(migrations)+1, name))
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(migrationTemplate); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return file, f.Close()
+}
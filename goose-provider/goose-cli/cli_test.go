@@ -0,0 +1,157 @@
+package goose_cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-provider-goose/common"
+	"terraform-provider-goose/goose-provider/internal/acctest"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+)
+
+func writeMigration(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	defer func(f func(context.Context, provider_config.State) (*provider_config.Config, error)) { newConfig = f }(newConfig)
+	newConfig = func(context.Context, provider_config.State) (*provider_config.Config, error) {
+		return acctest.Config(acctest.StaticTokenSource{}), nil
+	}
+
+	dir := t.TempDir()
+	writeMigration(t, dir, "001_orders.sql", "-- +goose Up\nCREATE TABLE orders (id INTEGER PRIMARY KEY);\n\n-- +goose Down\nDROP TABLE orders;\n")
+	writeMigration(t, dir, "002_payments.sql", "-- +goose Up\nCREATE TABLE payments (id INTEGER PRIMARY KEY);\n\n-- +goose Down\nDROP TABLE payments;\n")
+	db := filepath.Join(t.TempDir(), "test.db")
+	connection := []string{"-endpoint", "localhost", "-database", db, "-dir", dir}
+
+	run := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), append(args, connection...), &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+
+	if out, errOut, code := run("status"); code != 0 || !strings.Contains(out, "Database is at version 0") {
+		t.Fatalf("status of a new database = %d\n%s%s", code, out, errOut)
+	}
+	conn, err := sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables int
+	err = conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, common.DefaultMigrationTable).Scan(&tables)
+	conn.Close()
+	if err != nil || tables != 0 {
+		t.Fatalf("status created the version table: %d, %v", tables, err)
+	}
+
+	if out, errOut, code := run("up", "-to", "1"); code != 0 || !strings.Contains(out, "Database is at version 1") {
+		t.Fatalf("up -to 1 = %d\n%s%s", code, out, errOut)
+	}
+	if out, errOut, code := run("status"); code != 0 || !strings.Contains(out, "applied  001_orders.sql") || !strings.Contains(out, "pending  002_payments.sql") {
+		t.Fatalf("status = %d\n%s%s", code, out, errOut)
+	}
+	if _, errOut, code := run("up", "-to", "0"); code != 1 || !strings.Contains(errOut, "below the current version 1, use down") {
+		t.Fatalf("up -to 0 = %d\n%s", code, errOut)
+	}
+	if out, errOut, code := run("up"); code != 0 || !strings.Contains(out, "up       002_payments.sql") || !strings.Contains(out, "Database is at version 2") {
+		t.Fatalf("up = %d\n%s%s", code, out, errOut)
+	}
+
+	if _, errOut, code := run("down"); code != 1 || !strings.Contains(errOut, "002_payments.sql:5: DROP TABLE payments;") {
+		t.Fatalf("down without -allow-destructive = %d\n%s", code, errOut)
+	}
+	if out, errOut, code := run("down", "-allow-destructive"); code != 0 || !strings.Contains(out, "Database is at version 1") {
+		t.Fatalf("down -allow-destructive = %d\n%s%s", code, out, errOut)
+	}
+	if out, errOut, code := run("redo", "-allow-destructive"); code != 0 || !strings.Contains(out, "down     001_orders.sql") || !strings.Contains(out, "up       001_orders.sql") {
+		t.Fatalf("redo = %d\n%s%s", code, out, errOut)
+	}
+
+	// Version 1 is missing from the version table, as if 2 had been applied out of order.
+	if out, errOut, code := run("up"); code != 0 {
+		t.Fatalf("up = %d\n%s%s", code, out, errOut)
+	}
+	conn, err = sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`DELETE FROM ` + common.DefaultMigrationTable + ` WHERE version_id = 1`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, errOut, code := run("status"); code != 0 || !strings.Contains(out, "pending  001_orders.sql") || !strings.Contains(out, "applied  002_payments.sql") {
+		t.Fatalf("status with a skipped migration = %d\n%s%s", code, out, errOut)
+	}
+
+	var stderr bytes.Buffer
+	if code := Run(context.Background(), append(append([]string{"up"}, connection...), "extra"), &bytes.Buffer{}, &stderr); code != 1 || !strings.Contains(stderr.String(), "takes no arguments") {
+		t.Fatalf("up with an argument = %d\n%s", code, stderr.String())
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	defer func(f func(context.Context, provider_config.State) (*provider_config.Config, error)) { newConfig = f }(newConfig)
+	newConfig = func(_ context.Context, state provider_config.State) (*provider_config.Config, error) {
+		config := acctest.Config(acctest.StaticTokenSource{})
+		config.ProviderState.AuditLogPath = state.AuditLogPath
+		return config, nil
+	}
+
+	dir := t.TempDir()
+	writeMigration(t, dir, "001_orders.sql", "-- +goose Up\nCREATE TABLE orders (id INTEGER PRIMARY KEY);\n\n-- +goose Down\nDROP TABLE orders;\n")
+	db := filepath.Join(t.TempDir(), "test.db")
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"up", "-endpoint", "grpc://localhost:2136/?database=" + db, "-dir", dir, "-audit-log", auditLog}
+	if code := Run(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("up = %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+
+	content, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record provider_config.AuditRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Endpoint != "localhost:2136" || record.Database != db || record.Action != "up" || record.ToVersion != 1 {
+		t.Fatalf("unexpected audit record %s", content)
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	file, err := createMigration([]string{dir}, "add orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "00001_add_orders.sql"); file != want {
+		t.Errorf("createMigration() = %s, want %s", file, want)
+	}
+
+	other := t.TempDir()
+	writeMigration(t, other, "007_payments.sql", "-- +goose Up\nSELECT 1;\n")
+	file, err = createMigration([]string{dir, other}, "refunds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "008_refunds.sql"); file != want {
+		t.Errorf("createMigration() = %s, want %s", file, want)
+	}
+	if _, err := common.ParseSQLMigrationFile(file); err != nil {
+		t.Errorf("created migration doesn't parse: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package goose_functions
+
+import (
+	"context"
+	"path/filepath"
+
+	"terraform-provider-goose/common"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var migrationAttrTypes = map[string]attr.Type{
+	"version": types.Int64Type,
+	"name":    types.StringType,
+	"path":    types.StringType,
+}
+
+var filenameAttrTypes = map[string]attr.Type{
+	"version":     types.Int64Type,
+	"description": types.StringType,
+	"type":        types.StringType,
+}
+
+type migrationModel struct {
+	Version int64  `tfsdk:"version"`
+	Name    string `tfsdk:"name"`
+	Path    string `tfsdk:"path"`
+}
+
+type filenameModel struct {
+	Version     int64  `tfsdk:"version"`
+	Description string `tfsdk:"description"`
+	Type        string `tfsdk:"type"`
+}
+
+// Functions returns the provider-defined functions.
+func Functions() []func() function.Function {
+	return []func() function.Function{
+		func() function.Function { return latestVersion{} },
+		func() function.Function { return listMigrations{} },
+		func() function.Function { return parseFilename{} },
+	}
+}
+
+func dirParameter() function.StringParameter {
+	return function.StringParameter{
+		Name:        "dir",
+		Description: "Directory of goose migration files.",
+	}
+}
+
+type latestVersion struct{}
+
+func (f latestVersion) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "latest_version"
+}
+
+func (f latestVersion) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Latest migration version",
+		Description: "Returns the version of the latest migration in the directory, or 0 if there are none.",
+		Parameters:  []function.Parameter{dirParameter()},
+		Return:      function.Int64Return{},
+	}
+}
+
+func (f latestVersion) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var dir string
+	resp.Error = req.Arguments.Get(ctx, &dir)
+	if resp.Error != nil {
+		return
+	}
+
+	migrations, err := common.CollectMigrations([]string{dir})
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	var version int64
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version
+	}
+	resp.Error = resp.Result.Set(ctx, version)
+}
+
+type listMigrations struct{}
+
+func (f listMigrations) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "list_migrations"
+}
+
+func (f listMigrations) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "List migrations",
+		Description: "Returns the migrations of the directory ordered by version, as objects with `version`, `name` and `path`.",
+		Parameters:  []function.Parameter{dirParameter()},
+		Return: function.ListReturn{
+			ElementType: types.ObjectType{AttrTypes: migrationAttrTypes},
+		},
+	}
+}
+
+func (f listMigrations) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var dir string
+	resp.Error = req.Arguments.Get(ctx, &dir)
+	if resp.Error != nil {
+		return
+	}
+
+	migrations, err := common.CollectMigrations([]string{dir})
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	list := []migrationModel{}
+	for _, migration := range migrations {
+		list = append(list, migrationModel{
+			Version: migration.Version,
+			Name:    filepath.Base(migration.Source),
+			Path:    migration.Source,
+		})
+	}
+	resp.Error = resp.Result.Set(ctx, list)
+}
+
+type parseFilename struct{}
+
+func (f parseFilename) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_filename"
+}
+
+func (f parseFilename) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Parse a migration file name",
+		Description: "Splits a goose migration file name like `00002_add_orders.sql` into `version`, `description` and `type`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "name",
+				Description: "Migration file name or path.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: filenameAttrTypes,
+		},
+	}
+}
+
+func (f parseFilename) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var name string
+	resp.Error = req.Arguments.Get(ctx, &name)
+	if resp.Error != nil {
+		return
+	}
+
+	file, err := common.ParseMigrationFilename(name)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, filenameModel{
+		Version:     file.Version,
+		Description: file.Description,
+		Type:        file.Type,
+	})
+}
@@ -0,0 +1,71 @@
+package goose_functions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func run(t *testing.T, f function.Function, arg string) (attr.Value, *function.FuncError) {
+	ctx := context.Background()
+	var definition function.DefinitionResponse
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+
+	result, funcErr := definition.Definition.Return.NewResultData(ctx)
+	if funcErr != nil {
+		t.Fatal(funcErr)
+	}
+	resp := function.RunResponse{Result: result}
+	f.Run(ctx, function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(arg)}),
+	}, &resp)
+	return resp.Result.Value(), resp.Error
+}
+
+func TestFunctions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"001_orders.sql", "002_payments.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("-- +goose Up\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := run(t, latestVersion{}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(types.Int64Value(2)) {
+		t.Errorf("latest_version() = %s, want 2", got)
+	}
+
+	got, err = run(t, listMigrations{}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := got.(types.List)
+	if !ok || len(list.Elements()) != 2 {
+		t.Fatalf("list_migrations() = %s, want 2 migrations", got)
+	}
+	first := list.Elements()[0].(types.Object).Attributes()
+	if !first["name"].Equal(types.StringValue("001_orders.sql")) {
+		t.Errorf("list_migrations()[0].name = %s, want 001_orders.sql", first["name"])
+	}
+
+	got, err = run(t, parseFilename{}, "00002_add_orders.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := got.(types.Object).Attributes()
+	if !parsed["version"].Equal(types.Int64Value(2)) || !parsed["description"].Equal(types.StringValue("add_orders")) {
+		t.Errorf("parse_filename() = %s", got)
+	}
+
+	if _, err := run(t, parseFilename{}, "orders.sql"); err == nil {
+		t.Error("parse_filename(\"orders.sql\") error = nil")
+	}
+}
@@ -0,0 +1,26 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+	"time"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+const (
+	auditActionUp      = "up"
+	auditActionDown    = "down"
+	auditActionDestroy = "destroy"
+)
+
+// audit appends a record of the action on a database of the fleet to the provider audit log.
+// A failure to write the record is reported as a warning: the migrations have already run.
+func (y *ydbMigrationFleet) audit(ctx context.Context, diags *diag.Diagnostics, params provider_config.ConnectionParams, action string, from int64, to int64, started time.Time, results []common.MigrationResult, actionErr error) {
+	record := provider_config.NewAuditRecord(action, params, from, to, started, results, actionErr)
+	if err := y.providerConfig.Audit(ctx, record); err != nil {
+		diags.AddWarning("Failed to write audit log", err.Error())
+	}
+}
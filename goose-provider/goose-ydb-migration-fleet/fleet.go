@@ -0,0 +1,75 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// forEachDatabase runs fn for every database, at most parallelism at a time.
+// With failFast the first failure cancels the databases which are still running or waiting.
+// It returns the errors keyed by database.
+func forEachDatabase(ctx context.Context, databases []string, parallelism int, failFast bool, fn func(ctx context.Context, database string) error) map[string]error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		errs    = map[string]error{}
+		done    int
+		workers = make(chan struct{}, parallelism)
+	)
+	for _, database := range databases {
+		wg.Add(1)
+		go func(database string) {
+			defer wg.Done()
+
+			var err error
+			select {
+			case workers <- struct{}{}:
+				if err = ctx.Err(); err == nil {
+					err = fn(ctx, database)
+				}
+				if err != nil && failFast {
+					// Cancel before the worker is released, so no waiting database starts.
+					cancel()
+				}
+				<-workers
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil {
+				errs[database] = err
+				tflog.Error(ctx, fmt.Sprintf("goose: %s failed (%d/%d): %v", database, done, len(databases), err))
+				return
+			}
+			tflog.Info(ctx, fmt.Sprintf("goose: %s done (%d/%d)", database, done, len(databases)))
+		}(database)
+	}
+	wg.Wait()
+	return errs
+}
+
+// fleetError describes the failed databases, one per line, in a stable order.
+func fleetError(errs map[string]error) string {
+	databases := make([]string, 0, len(errs))
+	for database := range errs {
+		databases = append(databases, database)
+	}
+	sort.Strings(databases)
+
+	lines := make([]string, 0, len(databases))
+	for _, database := range databases {
+		lines = append(lines, fmt.Sprintf("%s: %v", database, errs[database]))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,48 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEachDatabase(t *testing.T) {
+	databases := []string{"/tenant1", "/tenant2", "/tenant3", "/tenant4"}
+	var running, maxRunning int32
+	errs := forEachDatabase(context.Background(), databases, 2, false, func(ctx context.Context, database string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		if database == "/tenant2" {
+			return errors.New("broken")
+		}
+		return nil
+	})
+	if len(errs) != 1 || errs["/tenant2"] == nil {
+		t.Errorf("forEachDatabase() errors = %v, want /tenant2 only", errs)
+	}
+	if maxRunning > 2 {
+		t.Errorf("forEachDatabase() ran %d databases at once, want at most 2", maxRunning)
+	}
+}
+
+func TestForEachDatabaseFailFast(t *testing.T) {
+	databases := []string{"/tenant1", "/tenant2", "/tenant3"}
+	var ran int32
+	errs := forEachDatabase(context.Background(), databases, 1, true, func(ctx context.Context, database string) error {
+		atomic.AddInt32(&ran, 1)
+		return errors.New("broken")
+	})
+	if ran != 1 {
+		t.Errorf("forEachDatabase() ran %d databases, want 1", ran)
+	}
+	if len(errs) != len(databases) {
+		t.Errorf("forEachDatabase() errors = %v, want an error for every database", errs)
+	}
+}
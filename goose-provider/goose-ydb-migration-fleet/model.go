@@ -0,0 +1,48 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const defaultParallelism = 4
+
+type ydbMigrationFleetDataModel struct {
+	Endpoint              types.String   `tfsdk:"endpoint"`
+	Databases             types.Set      `tfsdk:"databases"`
+	TlsEnabled            types.Bool     `tfsdk:"tls_enabled"`
+	MigrationTable        types.String   `tfsdk:"migration_table"`
+	MigrationsDir         types.String   `tfsdk:"migrations_dir"`
+	TargetVersion         types.Int64    `tfsdk:"target_version"`
+	Parallelism           types.Int64    `tfsdk:"parallelism"`
+	AllowDestructive      types.Bool     `tfsdk:"allow_destructive"`
+	AllowIrreversibleSkip types.Bool     `tfsdk:"allow_irreversible_skip"`
+	FailFast              types.Bool     `tfsdk:"fail_fast"`
+	Versions              types.Map      `tfsdk:"versions"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (m ydbMigrationFleetDataModel) parallelism() int {
+	if m.Parallelism.IsNull() || m.Parallelism.IsUnknown() {
+		return defaultParallelism
+	}
+	return int(m.Parallelism.ValueInt64())
+}
+
+func (m ydbMigrationFleetDataModel) databases(ctx context.Context) ([]string, diag.Diagnostics) {
+	var databases []string
+	diags := m.Databases.ElementsAs(ctx, &databases, false)
+	return databases, diags
+}
+
+func (m ydbMigrationFleetDataModel) versions(ctx context.Context) (map[string]int64, diag.Diagnostics) {
+	versions := map[string]int64{}
+	var diags diag.Diagnostics
+	if !m.Versions.IsNull() && !m.Versions.IsUnknown() {
+		diags = m.Versions.ElementsAs(ctx, &versions, false)
+	}
+	return versions, diags
+}
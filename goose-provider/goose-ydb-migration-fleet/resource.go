@@ -0,0 +1,444 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+)
+
+type ydbMigrationFleet struct {
+	providerConfig *provider_config.Config
+}
+
+func NewResource() resource.Resource {
+	return &ydbMigrationFleet{}
+}
+
+func (y *ydbMigrationFleet) Metadata(_ context.Context, _ resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = "goose_ydb_migration_fleet"
+}
+
+func (y *ydbMigrationFleet) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Description: "Applies one migration set to many databases of the same endpoint.",
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Required: true,
+			},
+			"databases": schema.SetAttribute{
+				Required:    true,
+				ElementType: types.StringType,
+				Description: "Paths of the databases to migrate. A database removed from the set is only removed from `versions`: " +
+					"its schema and version table stay in place, and the apply warns about it. Destroy the resource or roll the " +
+					"database back separately to undo its migrations.",
+				Validators: []validator.Set{
+					setvalidator.SizeAtLeast(1),
+				},
+			},
+			"tls_enabled": schema.BoolAttribute{
+				Optional: true,
+			},
+			"migration_table": schema.StringAttribute{
+				Optional: true,
+			},
+			"migrations_dir": schema.StringAttribute{
+				Required: true,
+				Validators: []validator.String{
+					common.DirValidator{},
+				},
+			},
+			"target_version": schema.Int64Attribute{
+				Optional: true,
+			},
+			"parallelism": schema.Int64Attribute{
+				Optional:    true,
+				Description: fmt.Sprintf("Maximum number of databases migrated at once. Defaults to %d.", defaultParallelism),
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"allow_destructive": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Allow pending migrations with destructive statements such as `DROP TABLE` or `ALTER TABLE ... DROP COLUMN`.",
+			},
+			"allow_irreversible_skip": schema.BoolAttribute{
+				Optional: true,
+				Description: "Allow rolling back past migrations without Down statements by recording them as rolled back without running anything. " +
+					"Applies to lowering the version and destroy.",
+			},
+			"fail_fast": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Stop migrating the remaining databases after the first failure.",
+			},
+			"versions": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.Int64Type,
+				Description: "Current migration version of every database. The version of a database which isn't at the target version is known after apply.",
+				PlanModifiers: []planmodifier.Map{
+					common.FleetVersionsPlanModifier(),
+				},
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (y *ydbMigrationFleet) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Info(ctx, "Creating migration fleet resource")
+
+	var plannedFleet ydbMigrationFleetDataModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plannedFleet)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, timeoutInitError := plannedFleet.Timeouts.Create(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	versions, diags := y.migrate(ctx, plannedFleet, map[string]int64{})
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plannedFleet.Versions, diags = types.MapValueFrom(ctx, types.Int64Type, versions)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plannedFleet)...)
+}
+
+func (y *ydbMigrationFleet) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	tflog.Info(ctx, "Reading migration fleet")
+	var stateFleet ydbMigrationFleetDataModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateFleet)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	versions, diags := stateFleet.versions(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	databases, diags := stateFleet.databases(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var mu sync.Mutex
+	errs := y.forEachDB(ctx, stateFleet, databases, false, &resp.Diagnostics, func(ctx context.Context, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error {
+		current, err := store.EnsureVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		versions[params.Database] = current
+		mu.Unlock()
+		return nil
+	})
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(errs) > 0 {
+		// An unreachable database keeps the version it had, so one tenant being down doesn't fail every plan.
+		resp.Diagnostics.AddWarning("Failed to get current migration version",
+			"The databases below keep their version from the state:\n"+fleetError(errs))
+	}
+
+	stateFleet.Versions, diags = types.MapValueFrom(ctx, types.Int64Type, versions)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &stateFleet)...)
+}
+
+func (y *ydbMigrationFleet) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Info(ctx, "Updating migration fleet resource")
+
+	var planFleet, stateFleet ydbMigrationFleetDataModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planFleet)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateFleet)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, timeoutInitError := planFleet.Timeouts.Update(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	current, diags := stateFleet.versions(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(removedDatabasesWarning(ctx, planFleet, current)...)
+
+	versions, diags := y.migrate(ctx, planFleet, current)
+	resp.Diagnostics.Append(diags...)
+	if versions == nil {
+		return
+	}
+	planFleet.Versions, diags = types.MapValueFrom(ctx, types.Int64Type, versions)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &planFleet)...)
+}
+
+// removedDatabasesWarning warns about the databases of the state which aren't in the plan anymore.
+// They aren't rolled back, so their schema stays in place while the fleet forgets them.
+func removedDatabasesWarning(ctx context.Context, plan ydbMigrationFleetDataModel, current map[string]int64) diag.Diagnostics {
+	databases, diags := plan.databases(ctx)
+	if diags.HasError() {
+		return diags
+	}
+	planned := make(map[string]bool, len(databases))
+	for _, database := range databases {
+		planned[database] = true
+	}
+	var removed []string
+	for database, version := range current {
+		if !planned[database] {
+			removed = append(removed, fmt.Sprintf("%s: version %d", database, version))
+		}
+	}
+	if len(removed) == 0 {
+		return diags
+	}
+	sort.Strings(removed)
+	diags.AddAttributeWarning(
+		path.Root("databases"),
+		"Databases removed from the fleet aren't rolled back",
+		fmt.Sprintf("These databases are no longer migrated by the resource. Their schema and version table stay at "+
+			"the version they reached:\n%s", strings.Join(removed, "\n")),
+	)
+	return diags
+}
+
+func (y *ydbMigrationFleet) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Deleting migration fleet resource")
+	var stateFleet ydbMigrationFleetDataModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateFleet)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	removeTimeout, timeoutInitError := stateFleet.Timeouts.Delete(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, removeTimeout)
+	defer cancel()
+
+	migrations, err := common.CollectMigrations([]string{stateFleet.MigrationsDir.ValueString()})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to collect migrations", err.Error())
+		return
+	}
+
+	databases, diags := stateFleet.databases(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var mu sync.Mutex
+	errs := y.forEachDB(ctx, stateFleet, databases, stateFleet.FailFast.ValueBool(), &resp.Diagnostics, func(ctx context.Context, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error {
+		started := time.Now()
+		from, err := store.EnsureVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		results, err := common.DownTo(ctx, db, store, migrations, 0, stateFleet.AllowIrreversibleSkip.ValueBool())
+		to, versionErr := store.EnsureVersion(ctx, db)
+		if versionErr != nil {
+			to = from
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		y.audit(ctx, &resp.Diagnostics, params, auditActionDestroy, from, to, started, results, err)
+		return err
+	})
+	if len(errs) > 0 {
+		resp.Diagnostics.AddError("Failed to roll back", fleetError(errs))
+	}
+}
+
+func (y *ydbMigrationFleet) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerConfig, ok := req.ProviderData.(*provider_config.Config)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider_config.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	y.providerConfig = providerConfig
+}
+
+// migrate moves the databases of the planned fleet which aren't at the target version according to current to it.
+// It returns the versions every database is at afterwards. A database whose version can't be read keeps its
+// version from current. The databases which fail are reported as a warning rather than an error: the resource
+// isn't tainted, its state records the versions they reached and the next apply retries only them.
+// It fails if no database could be migrated.
+func (y *ydbMigrationFleet) migrate(ctx context.Context, plannedFleet ydbMigrationFleetDataModel, current map[string]int64) (map[string]int64, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	databases, d := plannedFleet.databases(ctx)
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	migrations, err := common.CollectMigrations([]string{plannedFleet.MigrationsDir.ValueString()})
+	if err != nil {
+		diags.AddError("Failed to collect migrations", err.Error())
+		return nil, diags
+	}
+	target, d := common.TargetVersion(migrations, plannedFleet.TargetVersion.ValueInt64Pointer())
+	diags.Append(d...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	versions := map[string]int64{}
+	var pending []string
+	for _, database := range databases {
+		if version, ok := current[database]; ok && version == target {
+			versions[database] = version
+			continue
+		}
+		pending = append(pending, database)
+	}
+	if len(pending) == 0 {
+		return versions, diags
+	}
+
+	var mu sync.Mutex
+	errs := y.forEachDB(ctx, plannedFleet, pending, plannedFleet.FailFast.ValueBool(), &diags, func(ctx context.Context, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error {
+		started := time.Now()
+		from, results, err := migrateDatabase(ctx, db, store, migrations, target, plannedFleet.AllowIrreversibleSkip.ValueBool())
+		to, versionErr := store.EnsureVersion(ctx, db)
+		mu.Lock()
+		defer mu.Unlock()
+		if versionErr == nil {
+			versions[params.Database] = to
+		} else {
+			to = from
+		}
+		action := auditActionUp
+		if target < from {
+			action = auditActionDown
+		}
+		y.audit(ctx, &diags, params, action, from, to, started, results, err)
+		return err
+	})
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	for _, database := range pending {
+		if _, ok := versions[database]; !ok {
+			versions[database] = current[database]
+		}
+	}
+	switch {
+	case len(errs) == len(pending):
+		diags.AddError("Failed to migrate", fleetError(errs))
+	case len(errs) > 0:
+		diags.AddWarning("Failed to migrate some databases",
+			fmt.Sprintf("%d of %d databases failed and keep the version they reached. The next apply retries them.\n%s",
+				len(errs), len(databases), fleetError(errs)))
+	}
+	return versions, diags
+}
+
+// forEachDB opens the databases of the fleet with a single IAM token and runs fn for each of them
+// with the store of the version table while holding the migration lock. It returns the errors keyed by database.
+func (y *ydbMigrationFleet) forEachDB(ctx context.Context, fleet ydbMigrationFleetDataModel, databases []string, failFast bool, diags *diag.Diagnostics, fn func(ctx context.Context, params provider_config.ConnectionParams, db *sql.DB, store *common.Store) error) map[string]error {
+	store, err := common.NewStore(y.providerConfig.Dialect, fleet.MigrationTable.ValueString(), y.providerConfig.Subject)
+	if err != nil {
+		diags.AddError("Failed to open version table", err.Error())
+		return nil
+	}
+
+	ctx, token, err := y.providerConfig.IAMToken(ctx)
+	if err != nil {
+		diags.AddError("Failed to open DB", err.Error())
+		return nil
+	}
+
+	unlock := common.LockMigrations(fleet.MigrationTable.ValueString())
+	defer unlock()
+
+	return forEachDatabase(ctx, databases, fleet.parallelism(), failFast, func(ctx context.Context, database string) error {
+		params, err := provider_config.ConnectionParamsFrom(ctx, y.providerConfig, "", fleet.Endpoint.ValueString(), database, fleet.TlsEnabled.ValueBoolPointer())
+		if err != nil {
+			return err
+		}
+		params.Token = token
+		db, err := y.providerConfig.OpenDBWithToken(ctx, params)
+		if err != nil {
+			return err
+		}
+		defer provider_config.CloseDB(ctx, db)
+		return fn(ctx, params, db, store)
+	})
+}
+
+// migrateDatabase migrates the database up or down to version.
+// It returns the version the database was at and the migrations which ran.
+func migrateDatabase(ctx context.Context, db *sql.DB, store *common.Store, migrations goose.Migrations, version int64, allowIrreversible bool) (int64, []common.MigrationResult, error) {
+	current, err := store.EnsureVersion(ctx, db)
+	if err != nil {
+		return 0, nil, err
+	}
+	var results []common.MigrationResult
+	switch {
+	case version > current:
+		results, err = common.UpTo(ctx, db, store, migrations, version)
+	case version < current:
+		results, err = common.DownTo(ctx, db, store, migrations, version, allowIrreversible)
+	}
+	return current, results, err
+}
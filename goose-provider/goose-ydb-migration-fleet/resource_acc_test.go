@@ -0,0 +1,218 @@
+package goose_ydb_migration_fleet_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"terraform-provider-goose/goose-provider/internal/acctest"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	_ "modernc.org/sqlite"
+)
+
+func testConfig(tenant1 string, tenant2 string, migrationsDir string, extra string) string {
+	return fmt.Sprintf(`
+resource "goose_ydb_migration_fleet" "tenants" {
+  endpoint       = "localhost"
+  databases      = [%q, %q]
+  migrations_dir = %q
+  %s
+}
+`, tenant1, tenant2, migrationsDir, extra)
+}
+
+func execSQL(t *testing.T, database string, query string) {
+	db, err := sql.Open("sqlite", database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(query); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkTables(database string, want ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		db, err := sql.Open("sqlite", database)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('orders', 'payments') ORDER BY name`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			got = append(got, name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("%s: tables = %v, want %v", database, got, want)
+		}
+		return rows.Err()
+	}
+}
+
+// checkAudit checks the outcome of the runs recorded in the audit log for database, one line per run.
+func checkAudit(auditLog string, database string, want ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		content, err := os.ReadFile(auditLog)
+		if err != nil {
+			return err
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			var record provider_config.AuditRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return err
+			}
+			if record.Database == database {
+				got = append(got, fmt.Sprintf("%s:%d->%d:%s", record.Action, record.FromVersion, record.ToVersion, record.Outcome))
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("%s: audit records = %v, want %v", database, got, want)
+		}
+		return nil
+	}
+}
+
+func TestAccMigrationFleet(t *testing.T) {
+	dir := t.TempDir()
+	tenant1 := filepath.Join(dir, "tenant1.db")
+	tenant2 := filepath.Join(dir, "tenant2.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(tenant1, tenant2, migrationsDir, "parallelism = 2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", "versions.%", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant1), "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant2), "2"),
+				),
+			},
+			{
+				// A removed database is forgotten, not rolled back.
+				Config: strings.Replace(testConfig(tenant1, tenant2, migrationsDir, "parallelism = 2"), fmt.Sprintf(", %q", tenant2), "", 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", "versions.%", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant1), "2"),
+					checkTables(tenant2, "orders", "payments"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccMigrationFleetPartialFailure(t *testing.T) {
+	dir := t.TempDir()
+	tenant1 := filepath.Join(dir, "tenant1.db")
+	tenant2 := filepath.Join(dir, "tenant2.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auditLog := filepath.Join(dir, "audit.jsonl")
+	config := acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})
+	config.ProviderState.AuditLogPath = types.StringValue(auditLog)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(config),
+		Steps: []resource.TestStep{
+			{
+				// The second migration fails on tenant2, where payments was created by hand.
+				PreConfig: func() {
+					execSQL(t, tenant2, "CREATE TABLE payments (id INTEGER PRIMARY KEY)")
+				},
+				Config: testConfig(tenant1, tenant2, migrationsDir, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant1), "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant2), "1"),
+					checkAudit(auditLog, tenant1, "up:0->2:success"),
+					checkAudit(auditLog, tenant2, "up:0->1:failure"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// The resource isn't tainted: the next apply only retries tenant2.
+				PreConfig: func() {
+					execSQL(t, tenant2, "DROP TABLE payments")
+				},
+				Config: testConfig(tenant1, tenant2, migrationsDir, ""),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("goose_ydb_migration_fleet.tenants", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant1), "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant2), "2"),
+					checkTables(tenant2, "orders", "payments"),
+					checkAudit(auditLog, tenant1, "up:0->2:success"),
+					checkAudit(auditLog, tenant2, "up:0->1:failure", "up:1->2:success"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccMigrationFleetRollbackAndDestroy(t *testing.T) {
+	dir := t.TempDir()
+	tenant1 := filepath.Join(dir, "tenant1.db")
+	tenant2 := filepath.Join(dir, "tenant2.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		CheckDestroy:             resource.ComposeTestCheckFunc(checkTables(tenant1), checkTables(tenant2)),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(tenant1, tenant2, migrationsDir, ""),
+				Check: resource.ComposeTestCheckFunc(
+					checkTables(tenant1, "orders", "payments"),
+					checkTables(tenant2, "orders", "payments"),
+				),
+			},
+			{
+				Config:      testConfig(tenant1, tenant2, migrationsDir, "target_version = 1"),
+				ExpectError: regexp.MustCompile("Destructive migration statements"),
+			},
+			{
+				Config: testConfig(tenant1, tenant2, migrationsDir, "target_version = 1\n  allow_destructive = true"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant1), "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration_fleet.tenants", fmt.Sprintf("versions.%s", tenant2), "1"),
+					checkTables(tenant1, "orders"),
+					checkTables(tenant2, "orders"),
+				),
+			},
+		},
+	})
+}
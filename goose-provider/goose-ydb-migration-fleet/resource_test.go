@@ -0,0 +1,29 @@
+package goose_ydb_migration_fleet
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRemovedDatabasesWarning(t *testing.T) {
+	ctx := context.Background()
+	plan := ydbMigrationFleetDataModel{
+		Databases: types.SetValueMust(types.StringType, []attr.Value{types.StringValue("/tenant1")}),
+	}
+
+	diags := removedDatabasesWarning(ctx, plan, map[string]int64{"/tenant1": 2, "/tenant2": 2, "/tenant3": 1})
+	if len(diags) != 1 || diags.HasError() {
+		t.Fatalf("removedDatabasesWarning() = %v, want one warning", diags)
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, "/tenant2: version 2\n/tenant3: version 1") || strings.Contains(detail, "/tenant1") {
+		t.Errorf("warning = %q, want the removed databases", detail)
+	}
+
+	if diags := removedDatabasesWarning(ctx, plan, map[string]int64{"/tenant1": 2}); len(diags) != 0 {
+		t.Errorf("removedDatabasesWarning() without removed databases = %v", diags)
+	}
+}
@@ -0,0 +1,73 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"database/sql"
+
+	"terraform-provider-goose/common"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var historyAttrTypes = map[string]attr.Type{
+	"version":          types.Int64Type,
+	"file_name":        types.StringType,
+	"checksum":         types.StringType,
+	"applied_by":       types.StringType,
+	"duration_ms":      types.Int64Type,
+	"terraform_run_id": types.StringType,
+}
+
+var historyType = types.ObjectType{AttrTypes: historyAttrTypes}
+
+func historySchema() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		Computed:    true,
+		Description: "Applied migrations as recorded in the version table.",
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"version": schema.Int64Attribute{
+					Computed: true,
+				},
+				"file_name": schema.StringAttribute{
+					Computed: true,
+				},
+				"checksum": schema.StringAttribute{
+					Computed:    true,
+					Description: "sha256 of the migration file when it was applied.",
+				},
+				"applied_by": schema.StringAttribute{
+					Computed: true,
+				},
+				"duration_ms": schema.Int64Attribute{
+					Computed: true,
+				},
+				"terraform_run_id": schema.StringAttribute{
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+// store returns the store of the version table. Migrations are recorded as applied by the subject of the credentials.
+func (y *ydbMigration) store(table string) (*common.Store, error) {
+	return common.NewStore(y.providerConfig.Dialect, table, y.providerConfig.Subject)
+}
+
+// readHistory reads the history columns of the version table.
+func readHistory(ctx context.Context, db *sql.DB, store *common.Store) (types.List, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	entries, err := store.ListHistory(ctx, db)
+	if err != nil {
+		diags.AddError("Failed to read version history", err.Error())
+		return types.ListNull(historyType), diags
+	}
+	if entries == nil {
+		entries = []common.HistoryEntry{}
+	}
+	return types.ListValueFrom(ctx, historyType, entries)
+}
@@ -0,0 +1,134 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"terraform-provider-goose/common"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type lastApplyModel struct {
+	FromVersion types.Int64  `tfsdk:"from_version"`
+	ToVersion   types.Int64  `tfsdk:"to_version"`
+	StartedAt   types.String `tfsdk:"started_at"`
+	FinishedAt  types.String `tfsdk:"finished_at"`
+	Migrations  types.List   `tfsdk:"migrations"`
+}
+
+type lastApplyMigrationModel struct {
+	Version    types.Int64  `tfsdk:"version"`
+	Source     types.String `tfsdk:"source"`
+	Direction  types.String `tfsdk:"direction"`
+	DurationMs types.Int64  `tfsdk:"duration_ms"`
+	Statements types.Int64  `tfsdk:"statements"`
+}
+
+var lastApplyMigrationAttrTypes = map[string]attr.Type{
+	"version":     types.Int64Type,
+	"source":      types.StringType,
+	"direction":   types.StringType,
+	"duration_ms": types.Int64Type,
+	"statements":  types.Int64Type,
+}
+
+var lastApplyAttrTypes = map[string]attr.Type{
+	"from_version": types.Int64Type,
+	"to_version":   types.Int64Type,
+	"started_at":   types.StringType,
+	"finished_at":  types.StringType,
+	"migrations":   types.ListType{ElemType: types.ObjectType{AttrTypes: lastApplyMigrationAttrTypes}},
+}
+
+func lastApplySchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Computed:    true,
+		Description: "Report of the last apply which ran migrations.",
+		Attributes: map[string]schema.Attribute{
+			"from_version": schema.Int64Attribute{
+				Computed: true,
+			},
+			"to_version": schema.Int64Attribute{
+				Computed: true,
+			},
+			"started_at": schema.StringAttribute{
+				Computed: true,
+			},
+			"finished_at": schema.StringAttribute{
+				Computed: true,
+			},
+			"migrations": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"version": schema.Int64Attribute{
+							Computed: true,
+						},
+						"source": schema.StringAttribute{
+							Computed: true,
+						},
+						"direction": schema.StringAttribute{
+							Computed: true,
+						},
+						"duration_ms": schema.Int64Attribute{
+							Computed: true,
+						},
+						"statements": schema.Int64Attribute{
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newLastApply reports the migrations run by an apply which started at started.
+func newLastApply(ctx context.Context, from int64, to int64, started time.Time, results []common.MigrationResult) (types.Object, diag.Diagnostics) {
+	migrations := make([]lastApplyMigrationModel, 0, len(results))
+	for _, result := range results {
+		migrations = append(migrations, lastApplyMigrationModel{
+			Version:    types.Int64Value(result.Version),
+			Source:     types.StringValue(result.Source),
+			Direction:  types.StringValue(result.Direction()),
+			DurationMs: types.Int64Value(result.Duration.Milliseconds()),
+			Statements: types.Int64Value(int64(result.Statements)),
+		})
+	}
+	migrationsList, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: lastApplyMigrationAttrTypes}, migrations)
+	if diags.HasError() {
+		return types.ObjectNull(lastApplyAttrTypes), diags
+	}
+
+	lastApply, d := types.ObjectValueFrom(ctx, lastApplyAttrTypes, lastApplyModel{
+		FromVersion: types.Int64Value(from),
+		ToVersion:   types.Int64Value(to),
+		StartedAt:   types.StringValue(started.UTC().Format(time.RFC3339)),
+		FinishedAt:  types.StringValue(time.Now().UTC().Format(time.RFC3339)),
+		Migrations:  migrationsList,
+	})
+	diags.Append(d...)
+	return lastApply, diags
+}
+
+// addSlowMigrationsWarning adds a summary warning listing the slow migrations of the apply.
+func addSlowMigrationsWarning(diags *diag.Diagnostics, results []common.MigrationResult) {
+	slow := common.SlowMigrations(results)
+	if len(slow) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(slow))
+	for _, result := range slow {
+		lines = append(lines, fmt.Sprintf("%s (%s): %s", result.Source, result.Direction(), result.Duration.Round(time.Millisecond)))
+	}
+	diags.AddWarning(
+		"Slow migrations",
+		fmt.Sprintf("%d migration(s) took longer than %s:\n%s", len(slow), common.SlowMigrationThreshold, strings.Join(lines, "\n")),
+	)
+}
@@ -1,19 +1,69 @@
 package goose_ydb_migration
 
 import (
-	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"context"
+
+	"terraform-provider-goose/common"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pressly/goose/v3"
 )
 
 type ydbMigrationDataModel struct {
-	Endpoint       types.String   `tfsdk:"endpoint"`
-	Database       types.String   `tfsdk:"database"`
-	TlsEnabled     types.Bool     `tfsdk:"tls_enabled"`
-	MigrationTable types.String   `tfsdk:"migration_table"`
-	MigrationsDir  types.String   `tfsdk:"migrations_dir"`
-	Version        types.Int64    `tfsdk:"version"`
-	TargetVersion  types.Int64    `tfsdk:"target_version"`
-	Migrations     types.List     `tfsdk:"migrations"`
-	Timeouts       timeouts.Value `tfsdk:"timeouts"`
+	Endpoint              types.String   `tfsdk:"endpoint"`
+	Database              types.String   `tfsdk:"database"`
+	DatabaseID            types.String   `tfsdk:"database_id"`
+	TlsEnabled            types.Bool     `tfsdk:"tls_enabled"`
+	MigrationTable        types.String   `tfsdk:"migration_table"`
+	DropOldTable          types.Bool     `tfsdk:"drop_old_migration_table"`
+	MigrationsDir         types.String   `tfsdk:"migrations_dir"`
+	MigrationsDirs        types.List     `tfsdk:"migrations_dirs"`
+	Version               types.Int64    `tfsdk:"version"`
+	TargetVersion         types.Int64    `tfsdk:"target_version"`
+	BaselineVersion       types.Int64    `tfsdk:"baseline_version"`
+	MaxSteps              types.Int64    `tfsdk:"max_steps"`
+	AllowDestructive      types.Bool     `tfsdk:"allow_destructive"`
+	AllowIrreversibleSkip types.Bool     `tfsdk:"allow_irreversible_skip"`
+	Migrations            types.List     `tfsdk:"migrations"`
+	RedoTrigger           types.String   `tfsdk:"redo_trigger"`
+	RedoVersions          types.Int64    `tfsdk:"redo_versions"`
+	RedoMigrations        types.List     `tfsdk:"redo_migrations"`
+	PendingUp             types.List     `tfsdk:"pending_up"`
+	PendingDown           types.List     `tfsdk:"pending_down"`
+	LastApply             types.Object   `tfsdk:"last_apply"`
+	History               types.List     `tfsdk:"history"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+}
+
+// redoVersions returns the number of migrations to redo when redo_trigger changes.
+func (m ydbMigrationDataModel) redoVersions() int64 {
+	if m.RedoVersions.IsNull() {
+		return common.DefaultRedoVersions
+	}
+	return m.RedoVersions.ValueInt64()
+}
+
+func (m ydbMigrationDataModel) hasMigrationsDirs() bool {
+	return m.MigrationsDir.ValueString() != "" || len(m.MigrationsDirs.Elements()) > 0
+}
+
+// collectMigrations collects the merged migration set of migrations_dir or migrations_dirs.
+func (m ydbMigrationDataModel) collectMigrations(ctx context.Context) (goose.Migrations, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	dirs := []string{m.MigrationsDir.ValueString()}
+	if m.MigrationsDir.ValueString() == "" {
+		dirs = nil
+		diags.Append(m.MigrationsDirs.ElementsAs(ctx, &dirs, false)...)
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+
+	migrations, err := common.CollectMigrations(dirs)
+	if err != nil {
+		diags.AddError("Failed to collect migrations", err.Error())
+	}
+	return migrations, diags
 }
@@ -0,0 +1,277 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+)
+
+// planDBTimeout limits how long the plan waits for the database before it falls back to the state.
+const planDBTimeout = 30 * time.Second
+
+// plannedVersionKey is the private state key of the version the plan was made against.
+const plannedVersionKey = "planned_version"
+
+func pendingSchema(direction string) schema.ListAttribute {
+	return schema.ListAttribute{
+		Computed:    true,
+		ElementType: types.StringType,
+		Description: fmt.Sprintf("Migrations the apply runs %s, in the order it runs them, as planned against the version "+
+			"the database is at, or against the state if the database can't be reached. Refreshing the state clears it.", direction),
+	}
+}
+
+// ModifyPlan plans the migrations the apply runs. The version of the database is read, without changing it,
+// when the database can be reached at plan time, so a database ahead of or behind the state is planned for
+// what it is: max_steps counts from it, and the migrations run from it are checked for destructive statements
+// and missing Down statements. Otherwise the plan relies on the version in the state.
+func (y *ydbMigration) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state ydbMigrationDataModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.Version.IsUnknown() || !plan.hasMigrationsDirs() {
+		if resp.Private != nil {
+			resp.Diagnostics.Append(resp.Private.SetKey(ctx, plannedVersionKey, nil)...)
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_up"), types.ListUnknown(types.StringType))...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_down"), types.ListUnknown(types.StringType))...)
+		return
+	}
+
+	migrations, diags := plan.collectMigrations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The apply moves a renamed version table before it migrates, so the versions are still in the old one.
+	tables := []string{plan.MigrationTable.ValueString()}
+	if !req.State.Raw.IsNull() && !state.MigrationTable.Equal(plan.MigrationTable) {
+		tables = append([]string{state.MigrationTable.ValueString()}, tables...)
+	}
+	current := state.Version.ValueInt64()
+	var applied []int64
+	version, versions, read := y.readAppliedVersions(ctx, plan, tables)
+	if read {
+		current, applied = version, versions
+		resp.Diagnostics.Append(versionGapsWarning(migrations, current, applied)...)
+	}
+	if resp.Private != nil {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, plannedVersionKey, []byte(strconv.FormatInt(current, 10)))...)
+	}
+	// The baseline is only recorded in an empty version table.
+	if req.State.Raw.IsNull() && !plan.BaselineVersion.IsNull() && len(applied) == 0 {
+		current = plan.BaselineVersion.ValueInt64()
+	}
+
+	// The version attribute counts max_steps from the state, which the database may be ahead of or behind.
+	version, ok, diags := common.PlannedVersion(ctx, req.Plan, migrations, current)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if ok && version != plan.Version.ValueInt64() {
+		plan.Version = types.Int64Value(version)
+		plan.Migrations, diags = common.MigrationList(ctx, migrations, version)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("version"), plan.Version)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("migrations"), plan.Migrations)...)
+	}
+	if req.State.Raw.IsNull() && !plan.BaselineVersion.IsNull() && plan.BaselineVersion.ValueInt64() > plan.Version.ValueInt64() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("baseline_version"),
+			"Baseline version after the target version",
+			fmt.Sprintf("baseline_version %d is after version %d the database is migrated to.",
+				plan.BaselineVersion.ValueInt64(), plan.Version.ValueInt64()),
+		)
+		return
+	}
+	resp.Diagnostics.Append(common.CheckPlannedMigrations(ctx, req.Plan, migrations, current, plan.Version.ValueInt64())...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.RedoTrigger.IsUnknown() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_up"), types.ListUnknown(types.StringType))...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_down"), types.ListUnknown(types.StringType))...)
+		return
+	}
+
+	var up, down goose.Migrations
+	pending, isUp := common.PendingMigrations(migrations, current, plan.Version.ValueInt64())
+	if isUp {
+		up = pending
+	} else {
+		down = pending
+	}
+	if !req.State.Raw.IsNull() && !plan.RedoTrigger.IsNull() && !plan.RedoTrigger.Equal(state.RedoTrigger) {
+		// The redo runs after the pending migrations, on the versions applied by then.
+		redoApplied := common.AppliedVersionsAt(migrations, plan.Version.ValueInt64())
+		if read {
+			redoApplied = common.AppliedVersionsAfter(applied, pending, isUp)
+		}
+		redo, err := common.RedoMigrations(migrations, redoApplied, plan.redoVersions())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("redo_versions"), "Failed to plan the redo", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(common.CheckRedo(ctx, req.Plan, redo)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		plan.RedoMigrations, diags = common.RedoList(ctx, redo)
+		resp.Diagnostics.Append(diags...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("redo_migrations"), plan.RedoMigrations)...)
+		for i := len(redo) - 1; i >= 0; i-- {
+			down = append(down, redo[i])
+		}
+		up = append(up, redo...)
+	}
+
+	if len(up) == 0 && len(down) == 0 && !req.State.Raw.IsNull() {
+		// Keep the lists of the last apply until a refresh clears them rather than planning an update
+		// which changes nothing but them.
+		plan.PendingUp, plan.PendingDown = state.PendingUp, state.PendingDown
+	} else {
+		plan.PendingUp, diags = pendingList(ctx, up)
+		resp.Diagnostics.Append(diags...)
+		plan.PendingDown, diags = pendingList(ctx, down)
+		resp.Diagnostics.Append(diags...)
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_up"), plan.PendingUp)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("pending_down"), plan.PendingDown)...)
+}
+
+// privateState is the private state of the resource, as passed to Update.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// plannedVersion returns the version of the database the plan was made against, or the version
+// in the state for a plan made before it was recorded.
+func plannedVersion(ctx context.Context, private privateState, state ydbMigrationDataModel) (int64, diag.Diagnostics) {
+	content, diags := private.GetKey(ctx, plannedVersionKey)
+	if diags.HasError() || len(content) == 0 {
+		return state.Version.ValueInt64(), diags
+	}
+	version, err := strconv.ParseInt(string(content), 10, 64)
+	if err != nil {
+		diags.AddError("Failed to read the planned version", err.Error())
+	}
+	return version, diags
+}
+
+// checkPlannedVersion fails if the database isn't at the version the plan was made against:
+// the migrations the apply would run aren't the ones the plan showed.
+func checkPlannedVersion(planned int64, current int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if planned != current {
+		diags.AddError(
+			"Database changed since the plan",
+			fmt.Sprintf("The plan was made for version %d, but the database is at version %d. "+
+				"Run terraform plan again to see the migrations the apply runs.", planned, current),
+		)
+	}
+	return diags
+}
+
+// readAppliedVersions reads the versions applied in the database from the first of the version tables
+// which exists. It reports false if the provider isn't configured yet, the connection details are only known
+// after apply or the database can't be reached.
+func (y *ydbMigration) readAppliedVersions(ctx context.Context, plan ydbMigrationDataModel, tables []string) (int64, []int64, bool) {
+	if y.providerConfig == nil || y.providerConfig.DBOpener == nil ||
+		plan.Endpoint.IsUnknown() || plan.Database.IsUnknown() || plan.DatabaseID.IsUnknown() ||
+		plan.TlsEnabled.IsUnknown() || plan.MigrationTable.IsUnknown() {
+		return 0, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, planDBTimeout)
+	defer cancel()
+
+	ctx, db, _, err := y.openDB(ctx, plan)
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("goose: planning from the state, the database can't be opened: %v", err))
+		return 0, nil, false
+	}
+	defer provider_config.CloseDB(ctx, db)
+
+	for i, table := range tables {
+		store, err := y.store(table)
+		if err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("goose: planning from the state: %v", err))
+			return 0, nil, false
+		}
+		if i < len(tables)-1 && !store.HasVersionTable(ctx, db) {
+			continue
+		}
+		current, applied, err := store.AppliedVersions(ctx, db)
+		if err != nil {
+			tflog.Warn(ctx, fmt.Sprintf("goose: planning from the state, the database can't be reached: %v", err))
+			return 0, nil, false
+		}
+		return current, applied, true
+	}
+	return 0, nil, false
+}
+
+// versionGapsWarning warns about migrations the apply won't run although they aren't applied,
+// and about applied versions without a migration file.
+func versionGapsWarning(migrations goose.Migrations, current int64, applied []int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+	missing, unknown := common.VersionGaps(migrations, current, applied)
+	if len(missing) > 0 {
+		diags.AddAttributeWarning(
+			path.Root("pending_up"),
+			"Migrations skipped by the database",
+			fmt.Sprintf("The database is at version %d, but these older migrations aren't applied to it:\n%s\n\n"+
+				"Migrating up doesn't run them. Roll back below them, or renumber them after the current version.",
+				current, strings.Join(missing, "\n")),
+		)
+	}
+	if len(unknown) > 0 {
+		versions := make([]string, 0, len(unknown))
+		for _, version := range unknown {
+			versions = append(versions, fmt.Sprint(version))
+		}
+		diags.AddAttributeWarning(
+			path.Root("pending_down"),
+			"Applied migrations without files",
+			fmt.Sprintf("These versions are applied to the database, but there are no migration files for them: %s.\n\n"+
+				"Rolling them back fails until the files are restored.", strings.Join(versions, ", ")),
+		)
+	}
+	return diags
+}
+
+// pendingList returns the sources of the migrations, or null if there are none.
+func pendingList(ctx context.Context, migrations goose.Migrations) (types.List, diag.Diagnostics) {
+	if len(migrations) == 0 {
+		return types.ListNull(types.StringType), nil
+	}
+	sources := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		sources = append(sources, migration.String())
+	}
+	return types.ListValueFrom(ctx, types.StringType, sources)
+}
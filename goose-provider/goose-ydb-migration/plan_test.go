@@ -0,0 +1,36 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type testPrivateState map[string][]byte
+
+func (p testPrivateState) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p[key], nil
+}
+
+func TestPlannedVersion(t *testing.T) {
+	ctx := context.Background()
+	state := ydbMigrationDataModel{Version: types.Int64Value(2)}
+
+	planned, diags := plannedVersion(ctx, testPrivateState{plannedVersionKey: []byte("3")}, state)
+	if diags.HasError() || planned != 3 {
+		t.Fatalf("planned version = %d, %v, want the version read at plan time", planned, diags)
+	}
+	planned, diags = plannedVersion(ctx, testPrivateState{}, state)
+	if diags.HasError() || planned != 2 {
+		t.Fatalf("planned version = %d, %v, want the state version", planned, diags)
+	}
+
+	if diags := checkPlannedVersion(3, 3); diags.HasError() {
+		t.Fatalf("unexpected error for the planned version: %v", diags)
+	}
+	if diags := checkPlannedVersion(2, 3); !diags.HasError() {
+		t.Fatal("no error for a database which changed since the plan")
+	}
+}
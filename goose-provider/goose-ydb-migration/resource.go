@@ -2,15 +2,20 @@ package goose_ydb_migration
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
-	"sort"
 	"strings"
+	"time"
 
 	"terraform-provider-goose/common"
 	provider_config "terraform-provider-goose/goose-provider/provider-config"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -19,7 +24,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/pressly/goose/v3"
 	"github.com/yandex-cloud/terraform-provider-yandex/yandex-framework/utils"
-	_ "github.com/ydb-platform/ydb-go-sdk/v3"
 )
 
 type ydbMigration struct {
@@ -36,12 +40,17 @@ func (y *ydbMigration) Metadata(_ context.Context, _ resource.MetadataRequest, r
 
 func (y *ydbMigration) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
 	response.Schema = schema.Schema{
+		Version: schemaVersion,
 		Attributes: map[string]schema.Attribute{
 			"endpoint": schema.StringAttribute{
-				Required: true,
+				Optional: true,
 			},
 			"database": schema.StringAttribute{
-				Required: true,
+				Optional: true,
+			},
+			"database_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "ID of the YDB database. The endpoint, the database path and TLS are looked up in Yandex Cloud. Conflicts with `endpoint` and `database`.",
 			},
 			"tls_enabled": schema.BoolAttribute{
 				Optional: true,
@@ -49,12 +58,25 @@ func (y *ydbMigration) Schema(ctx context.Context, _ resource.SchemaRequest, res
 			"migration_table": schema.StringAttribute{
 				Optional: true,
 			},
+			"drop_old_migration_table": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Drop the previous version table after its history is copied to a changed `migration_table`.",
+			},
 			"migrations_dir": schema.StringAttribute{
-				Required: true,
+				Optional: true,
 				Validators: []validator.String{
 					common.DirValidator{},
 				},
 			},
+			"migrations_dirs": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Ordered list of directories whose migrations are merged into one set. Conflicts with `migrations_dir`.",
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.ValueStringsAre(common.DirValidator{}),
+				},
+			},
 			"version": schema.Int64Attribute{
 				Computed: true,
 				PlanModifiers: []planmodifier.Int64{
@@ -64,6 +86,31 @@ func (y *ydbMigration) Schema(ctx context.Context, _ resource.SchemaRequest, res
 			"target_version": schema.Int64Attribute{
 				Optional: true,
 			},
+			"baseline_version": schema.Int64Attribute{
+				Optional: true,
+				Description: "Version the schema of an existing database is at. On create, the migrations up to it are recorded " +
+					"as applied without running them if the version table has no versions recorded, then the rest are applied. " +
+					"Changing it later has no effect.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"allow_destructive": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Allow pending migrations with destructive statements such as `DROP TABLE` or `ALTER TABLE ... DROP COLUMN`.",
+			},
+			"allow_irreversible_skip": schema.BoolAttribute{
+				Optional: true,
+				Description: "Allow rolling back past migrations without Down statements by recording them as rolled back without running anything. " +
+					"Applies to lowering the version and destroy; a redo of such a migration always fails.",
+			},
+			"max_steps": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of migrations run by a single apply. The remaining migrations stay pending for the next apply.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
 			"migrations": schema.ListAttribute{
 				Computed:    true,
 				ElementType: types.StringType,
@@ -71,6 +118,28 @@ func (y *ydbMigration) Schema(ctx context.Context, _ resource.SchemaRequest, res
 					common.MigrationsPlanModifier(),
 				},
 			},
+			"redo_trigger": schema.StringAttribute{
+				Optional:    true,
+				Description: "Arbitrary value; changing it re-applies (down, then up) the latest migrations without replacing the resource.",
+			},
+			"redo_versions": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Number of the latest versions applied in the database to redo when `redo_trigger` changes. Defaults to 1.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"redo_migrations": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				PlanModifiers: []planmodifier.List{
+					common.RedoMigrationsPlanModifier(),
+				},
+			},
+			"pending_up":   pendingSchema("up"),
+			"pending_down": pendingSchema("down"),
+			"last_apply":   lastApplySchema(),
+			"history":      historySchema(),
 			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
 				Create: true,
 				Update: true,
@@ -80,12 +149,36 @@ func (y *ydbMigration) Schema(ctx context.Context, _ resource.SchemaRequest, res
 	}
 }
 
+func (y *ydbMigration) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("migrations_dir"),
+			path.MatchRoot("migrations_dirs"),
+		),
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("database"),
+		),
+		resourcevalidator.RequiredTogether(
+			path.MatchRoot("endpoint"),
+			path.MatchRoot("database"),
+		),
+		resourcevalidator.Conflicting(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("tls_enabled"),
+		),
+	}
+}
+
 func (y *ydbMigration) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	tflog.Info(ctx, "Creating project resource")
 
 	var plannedMigration ydbMigrationDataModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plannedMigration)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	createTimeout, timeoutInitError := plannedMigration.Timeouts.Create(ctx, common.DefaultTimeout)
 	if timeoutInitError != nil {
@@ -96,40 +189,64 @@ func (y *ydbMigration) Create(ctx context.Context, req resource.CreateRequest, r
 	ctx, cancel := context.WithTimeout(ctx, createTimeout)
 	defer cancel()
 
-	iamToken, err := y.providerConfig.SDK.CreateIAMToken(ctx)
-	ctx = tflog.MaskMessageStrings(ctx, iamToken.IamToken)
+	ctx, db, params, err := y.openDB(ctx, plannedMigration)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create IAM token", err.Error())
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
 		return
 	}
+	defer provider_config.CloseDB(ctx, db)
 
-	dbString := makeDbString(
-		plannedMigration.Endpoint.ValueString(),
-		plannedMigration.Database.ValueString(),
-		iamToken.IamToken,
-		plannedMigration.TlsEnabled.ValueBoolPointer(),
-	)
+	unlock := common.LockMigrations(plannedMigration.MigrationTable.ValueString())
+	defer unlock()
+
+	migrations, diags := plannedMigration.collectMigrations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	db, err := goose.OpenDBWithDriver("ydb", dbString)
+	store, err := y.store(plannedMigration.MigrationTable.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to open DB", err.Error())
+		resp.Diagnostics.AddError("Failed to open version table", err.Error())
 		return
 	}
 
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Fatalf("goose: failed to close DB: %v\n", err)
+	started := time.Now()
+	if !plannedMigration.BaselineVersion.IsNull() {
+		baseline := plannedMigration.BaselineVersion.ValueInt64()
+		baselined, err := common.Baseline(ctx, db, store, migrations, baseline)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("baseline_version"), "Failed to record baseline version", err.Error())
+			return
 		}
-	}()
-
-	if plannedMigration.MigrationTable.ValueString() != "" {
-		goose.SetTableName(plannedMigration.MigrationTable.ValueString())
+		if !baselined {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("baseline_version"),
+				"Baseline version not recorded",
+				fmt.Sprintf("The version table %s has versions recorded already, so the migrations up to version %d "+
+					"aren't recorded as applied. The database is migrated from the version it is at.",
+					store.Tablename(), baseline),
+			)
+		}
+	}
+	from, err := store.EnsureVersion(ctx, db)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get current migration version", err.Error())
+		return
 	}
 
-	if err := goose.UpContext(ctx, db, plannedMigration.MigrationsDir.ValueString()); err != nil {
+	results, err := common.UpTo(ctx, db, store, migrations, plannedMigration.Version.ValueInt64())
+	y.audit(ctx, &resp.Diagnostics, params, auditActionUp, from, plannedMigration.Version.ValueInt64(), started, results, err)
+	if err != nil {
 		resp.Diagnostics.AddError("Failed to migrate", err.Error())
 		return
 	}
+	addSlowMigrationsWarning(&resp.Diagnostics, results)
+
+	plannedMigration.LastApply, diags = newLastApply(ctx, from, plannedMigration.Version.ValueInt64(), started, results)
+	resp.Diagnostics.Append(diags...)
+	plannedMigration.History, diags = readHistory(ctx, db, store)
+	resp.Diagnostics.Append(diags...)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plannedMigration)...)
 }
@@ -139,37 +256,27 @@ func (y *ydbMigration) Read(ctx context.Context, req resource.ReadRequest, resp
 	var stateMigration ydbMigrationDataModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &stateMigration)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	iamToken, err := y.providerConfig.SDK.CreateIAMToken(ctx)
-	ctx = tflog.MaskMessageStrings(ctx, iamToken.IamToken)
+	ctx, db, _, err := y.openDB(ctx, stateMigration)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create IAM token", err.Error())
-		tflog.Error(ctx, "Failed to create IAM token")
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
 		return
 	}
+	defer provider_config.CloseDB(ctx, db)
 
-	dbString := makeDbString(
-		stateMigration.Endpoint.ValueString(),
-		stateMigration.Database.ValueString(),
-		iamToken.IamToken,
-		stateMigration.TlsEnabled.ValueBoolPointer(),
-	)
-	tflog.Info(ctx, fmt.Sprintf("DB string: %s", dbString))
+	unlock := common.LockMigrations(stateMigration.MigrationTable.ValueString())
+	defer unlock()
 
-	db, err := goose.OpenDBWithDriver("ydb", dbString)
+	store, err := y.store(stateMigration.MigrationTable.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to open DB", err.Error())
-		tflog.Error(ctx, fmt.Sprintf("Failed to open DB %s", dbString))
+		resp.Diagnostics.AddError("Failed to open version table", err.Error())
 		return
 	}
 
-	defer func() {
-		if err := db.Close(); err != nil {
-			resp.Diagnostics.AddError("Failed to close DB", err.Error())
-		}
-	}()
-
-	current, err := goose.GetDBVersionContext(ctx, db)
+	current, err := store.EnsureVersion(ctx, db)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get current migration version", err.Error())
 		tflog.Error(ctx, "Failed to get current migration version")
@@ -178,6 +285,29 @@ func (y *ydbMigration) Read(ctx context.Context, req resource.ReadRequest, resp
 	tflog.Info(ctx, fmt.Sprintf("Current version: %d", current))
 
 	stateMigration.Version = types.Int64Value(current)
+	// The state is what the database is at, so nothing is pending for it.
+	stateMigration.PendingUp = types.ListNull(types.StringType)
+	stateMigration.PendingDown = types.ListNull(types.StringType)
+
+	var diags diag.Diagnostics
+	stateMigration.History, diags = readHistory(ctx, db, store)
+	resp.Diagnostics.Append(diags...)
+
+	if stateMigration.hasMigrationsDirs() {
+		migrations, diags := stateMigration.collectMigrations(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var applied []string
+		for _, migration := range migrations {
+			if migration.Version <= current {
+				applied = append(applied, migration.String())
+			}
+		}
+		stateMigration.Migrations, diags = types.ListValueFrom(ctx, types.StringType, applied)
+		resp.Diagnostics.Append(diags...)
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &stateMigration)...)
 }
@@ -187,6 +317,12 @@ func (y *ydbMigration) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	var planMigration, stateMigration ydbMigrationDataModel
 
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planMigration)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateMigration)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	updateTimeout, timeoutInitError := planMigration.Timeouts.Update(ctx, utils.DefaultTimeout)
 	if timeoutInitError != nil {
 		resp.Diagnostics.Append(timeoutInitError...)
@@ -196,49 +332,92 @@ func (y *ydbMigration) Update(ctx context.Context, req resource.UpdateRequest, r
 	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
 	defer cancel()
 
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &planMigration)...)
-	resp.Diagnostics.Append(req.State.Get(ctx, &stateMigration)...)
-
-	if planMigration.MigrationTable.ValueString() != stateMigration.MigrationTable.ValueString() {
-		resp.Diagnostics.AddError(
-			"Cannot change migration_table",
-			fmt.Sprintf("Migration table cannot be changed"),
-		)
+	ctx, db, params, err := y.openDB(ctx, planMigration)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
 		return
 	}
+	defer provider_config.CloseDB(ctx, db)
+
+	unlock := common.LockMigrations(planMigration.MigrationTable.ValueString())
+	defer unlock()
 
-	iamToken, err := y.providerConfig.SDK.CreateIAMToken(ctx)
-	ctx = tflog.MaskMessageStrings(ctx, iamToken.IamToken)
+	store, err := y.store(planMigration.MigrationTable.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to open version table", err.Error())
+		return
+	}
+	old, err := y.store(stateMigration.MigrationTable.ValueString())
 	if err != nil {
+		resp.Diagnostics.AddError("Failed to open version table", err.Error())
+		return
+	}
+	if err := moveVersionTable(ctx, db, old, store, planMigration.DropOldTable.ValueBool()); err != nil {
+		resp.Diagnostics.AddError("Failed to change migration_table", err.Error())
 		return
 	}
 
-	dbString := makeDbString(
-		stateMigration.Endpoint.ValueString(),
-		stateMigration.Database.ValueString(),
-		iamToken.IamToken,
-		stateMigration.TlsEnabled.ValueBoolPointer(),
-	)
+	migrations, diags := planMigration.collectMigrations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	db, err := goose.OpenDBWithDriver("ydb", dbString)
+	// Run what the plan showed: it was made against the version of the database, or the state if the
+	// database couldn't be read, and an apply from any other version would run other migrations.
+	planned, diags := plannedVersion(ctx, req.Private, stateMigration)
+	resp.Diagnostics.Append(diags...)
+	from, err := store.EnsureVersion(ctx, db)
 	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("goose: failed to open DB: %v\n", err))
+		resp.Diagnostics.AddError("Failed to get current migration version", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(checkPlannedVersion(planned, from)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if resp.Private != nil {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, plannedVersionKey, nil)...)
 	}
 
-	defer func() {
-		if err := db.Close(); err != nil {
-			tflog.Error(ctx, fmt.Sprintf("goose: failed to close DB: %v\n", err))
+	started := time.Now()
+	var results []common.MigrationResult
+	to := planMigration.Version.ValueInt64()
+	if to > from {
+		results, err = common.UpTo(ctx, db, store, migrations, to)
+		y.audit(ctx, &resp.Diagnostics, params, auditActionUp, from, to, started, results, err)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to migrate", err.Error())
+			return
 		}
-	}()
-	if planMigration.Version.ValueInt64() > stateMigration.Version.ValueInt64() {
-		if err := goose.UpToContext(ctx, db, planMigration.MigrationsDir.ValueString(), planMigration.Version.ValueInt64()); err != nil {
-			tflog.Error(ctx, fmt.Sprintf("goose up: %v", err))
+	} else if to < from {
+		results, err = common.DownTo(ctx, db, store, migrations, to, planMigration.AllowIrreversibleSkip.ValueBool())
+		y.audit(ctx, &resp.Diagnostics, params, auditActionDown, from, to, started, results, err)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to roll back", err.Error())
+			return
 		}
-	} else if planMigration.Version.ValueInt64() < stateMigration.Version.ValueInt64() {
-		if err := goose.DownToContext(ctx, db, planMigration.MigrationsDir.ValueString(), planMigration.Version.ValueInt64()); err != nil {
-			tflog.Error(ctx, fmt.Sprintf("goose down: %v", err))
+	}
+
+	if !planMigration.RedoTrigger.IsNull() && !planMigration.RedoTrigger.Equal(stateMigration.RedoTrigger) {
+		redoStarted := time.Now()
+		redoResults, err := redo(ctx, db, store, migrations, planMigration)
+		y.audit(ctx, &resp.Diagnostics, params, auditActionRedo, to, to, redoStarted, redoResults, err)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to redo migrations", err.Error())
+			return
 		}
+		results = append(results, redoResults...)
 	}
+	addSlowMigrationsWarning(&resp.Diagnostics, results)
+
+	planMigration.LastApply = stateMigration.LastApply
+	if len(results) > 0 {
+		planMigration.LastApply, diags = newLastApply(ctx, from, to, started, results)
+		resp.Diagnostics.Append(diags...)
+	}
+	planMigration.History, diags = readHistory(ctx, db, store)
+	resp.Diagnostics.Append(diags...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &planMigration)...)
 }
 
@@ -247,6 +426,9 @@ func (y *ydbMigration) Delete(ctx context.Context, req resource.DeleteRequest, r
 	var stateMigration ydbMigrationDataModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &stateMigration)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	removeTimeout, timeoutInitError := stateMigration.Timeouts.Delete(ctx, utils.DefaultTimeout)
 	if timeoutInitError != nil {
@@ -257,34 +439,61 @@ func (y *ydbMigration) Delete(ctx context.Context, req resource.DeleteRequest, r
 	ctx, cancel := context.WithTimeout(ctx, removeTimeout)
 	defer cancel()
 
-	iamToken, err := y.providerConfig.SDK.CreateIAMToken(ctx)
-	ctx = tflog.MaskMessageStrings(ctx, iamToken.IamToken)
+	ctx, db, params, err := y.openDB(ctx, stateMigration)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create IAM token", err.Error())
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
+		return
 	}
+	defer provider_config.CloseDB(ctx, db)
 
-	dbString := makeDbString(
-		stateMigration.Endpoint.ValueString(),
-		stateMigration.Database.ValueString(),
-		iamToken.IamToken,
-		stateMigration.TlsEnabled.ValueBoolPointer(),
-	)
+	migrations, diags := stateMigration.collectMigrations(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	unlock := common.LockMigrations(stateMigration.MigrationTable.ValueString())
+	defer unlock()
 
-	db, err := goose.OpenDBWithDriver("ydb", dbString)
+	store, err := y.store(stateMigration.MigrationTable.ValueString())
 	if err != nil {
-		tflog.Error(ctx, fmt.Sprintf("goose: failed to open DB: %v\n", err))
+		resp.Diagnostics.AddError("Failed to open version table", err.Error())
+		return
 	}
 
-	defer func() {
-		if err := db.Close(); err != nil {
-			tflog.Error(ctx, fmt.Sprintf("goose: failed to close DB: %v\n", err))
-		}
-	}()
+	started := time.Now()
+	from, err := store.EnsureVersion(ctx, db)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get current migration version", err.Error())
+		return
+	}
+	results, err := common.DownTo(ctx, db, store, migrations, 0, stateMigration.AllowIrreversibleSkip.ValueBool())
+	y.audit(ctx, &resp.Diagnostics, params, auditActionDestroy, from, 0, started, results, err)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to roll back", err.Error())
+	}
+}
 
-	if err := goose.DownToContext(ctx, db, stateMigration.MigrationsDir.ValueString(), 0); err != nil {
-		tflog.Error(ctx, fmt.Sprintf("goose down: %v", err))
+// ImportState imports the migration state of a database by its `<endpoint><database>` ID,
+// e.g. `ydb.serverless.yandexcloud.net:2135/ru-central1/b1g/etn`, or by its Yandex Cloud database ID.
+func (y *ydbMigration) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	i := strings.Index(req.ID, "/")
+	if i < 0 && req.ID != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database_id"), req.ID)...)
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("redo_migrations"), []string{})...)
+		return
+	}
+	if i <= 0 {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			fmt.Sprintf("Expected import identifier with format: <endpoint>/<database path> or <database id>. Got: %q", req.ID),
+		)
+		return
 	}
 
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("endpoint"), req.ID[:i])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("database"), req.ID[i:])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("redo_migrations"), []string{})...)
 }
 
 func (y *ydbMigration) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -304,31 +513,18 @@ func (y *ydbMigration) Configure(_ context.Context, req resource.ConfigureReques
 	y.providerConfig = providerConfig
 }
 
-func makeDbString(endpoint string, database string, token string, tlsEnabled *bool) string {
-	tls := "grpcs"
-	if tlsEnabled != nil && !*tlsEnabled {
-		tls = "grpc"
-	}
-	options := map[string]string{
-		"go_query_mode": "scripting",
-		"go_fake_tx":    "scripting",
-		"go_query_bind": "declare,numeric",
-		"token":         token,
-	}
-	q := strings.Builder{}
-	keys := make([]string, 0, len(options))
-	for k := range options {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		if q.Len() > 0 {
-			q.WriteByte('&')
-		}
-		q.WriteString(k)
-		q.WriteByte('=')
-		q.WriteString(options[k])
+// openDB opens the database of the migration, resolving database_id if it is set, and returns its connection details.
+func (y *ydbMigration) openDB(ctx context.Context, migration ydbMigrationDataModel) (context.Context, *sql.DB, provider_config.ConnectionParams, error) {
+	params, err := provider_config.ConnectionParamsFrom(ctx, y.providerConfig,
+		migration.DatabaseID.ValueString(), migration.Endpoint.ValueString(), migration.Database.ValueString(), migration.TlsEnabled.ValueBoolPointer())
+	if err != nil {
+		return ctx, nil, params, err
 	}
+	ctx, db, err := y.providerConfig.OpenDB(ctx, params)
+	return ctx, db, params, err
+}
 
-	return fmt.Sprintf("%s://%s%s?%s", tls, endpoint, database, q.String())
+// redo rolls back the latest applied migrations planned for redo and applies them again.
+func redo(ctx context.Context, db *sql.DB, store *common.Store, migrations goose.Migrations, planMigration ydbMigrationDataModel) ([]common.MigrationResult, error) {
+	return common.Redo(ctx, db, store, migrations, planMigration.redoVersions())
 }
@@ -0,0 +1,307 @@
+package goose_ydb_migration_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"terraform-provider-goose/goose-provider/internal/acctest"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	_ "modernc.org/sqlite"
+)
+
+func testConfig(database string, migrationsDir string, extra string) string {
+	return fmt.Sprintf(`
+resource "goose_ydb_migration" "db" {
+  endpoint       = "localhost"
+  database       = %q
+  migrations_dir = %q
+  %s
+}
+`, database, migrationsDir, extra)
+}
+
+func checkTables(database string, want ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		db, err := sql.Open("sqlite", database)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('orders', 'payments') ORDER BY name`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return err
+			}
+			got = append(got, name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("tables = %v, want %v", got, want)
+		}
+		return rows.Err()
+	}
+}
+
+func TestAccMigration(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		CheckDestroy:             checkTables(database),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, migrationsDir, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "migrations.#", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.from_version", "0"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.to_version", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.migrations.#", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "pending_up.#", "2"),
+					checkTables(database, "orders", "payments"),
+				),
+			},
+			{
+				Config: testConfig(database, migrationsDir, "target_version = 1\n  allow_destructive = true"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "migrations.#", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.migrations.0.direction", "down"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "pending_down.#", "1"),
+					checkTables(database, "orders"),
+				),
+			},
+			{
+				Config: testConfig(database, migrationsDir, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "2"),
+					checkTables(database, "orders", "payments"),
+				),
+			},
+			{
+				ResourceName:                         "goose_ydb_migration.db",
+				ImportState:                          true,
+				ImportStateId:                        "localhost" + database,
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "database",
+				ImportStateVerifyIgnore:              []string{"migrations_dir", "migrations", "last_apply", "pending_up", "pending_down"},
+			},
+		},
+	})
+}
+
+func TestAccMigrationRenamedVersionTable(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, migrationsDir, ""),
+				Check:  resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "2"),
+			},
+			{
+				// The plan reads the version from the old table, which the apply moves before it rolls back.
+				Config: testConfig(database, migrationsDir, `migration_table = "schema_version"
+  drop_old_migration_table = true
+  target_version = 1
+  allow_destructive = true`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "1"),
+					resource.TestCheckNoResourceAttr("goose_ydb_migration.db", "pending_up"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "pending_down.#", "1"),
+					checkTables(database, "orders"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccMigrationBaseline(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					// The schema of the first migration was created by hand.
+					db, err := sql.Open("sqlite", database)
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer db.Close()
+					if _, err := db.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY)"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testConfig(database, migrationsDir, "baseline_version = 1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "2"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "pending_up.#", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.from_version", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "last_apply.migrations.#", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "history.#", "2"),
+					checkTables(database, "orders", "payments"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccMigrationDestructiveRollback(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, migrationsDir, ""),
+			},
+			{
+				Config:      testConfig(database, migrationsDir, "target_version = 1"),
+				ExpectError: regexp.MustCompile("Destructive migration statements"),
+			},
+			{
+				// Redo rolls the last migration back first, which drops its table.
+				Config:      testConfig(database, migrationsDir, `redo_trigger = "1"`),
+				ExpectError: regexp.MustCompile("The migrations to redo contain destructive statements"),
+			},
+		},
+	})
+}
+
+func TestAccMigrationIrreversibleRedo(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(migrationsDir, "001_orders.sql"), []byte("-- +goose Up\nCREATE TABLE orders (id INTEGER);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				// Destroy skips the missing Down statements.
+				Config: testConfig(database, migrationsDir, "allow_irreversible_skip = true"),
+			},
+			{
+				// Skipping the Down statements would run CREATE TABLE again on the existing table.
+				Config:      testConfig(database, migrationsDir, "redo_trigger = \"1\"\n  allow_irreversible_skip = true"),
+				ExpectError: regexp.MustCompile("Irreversible migrations"),
+			},
+		},
+	})
+}
+
+func TestAccMigrationDatabaseAheadOfState(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, migrationsDir, "target_version = 1"),
+				Check:  resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "1"),
+			},
+			{
+				PreConfig: func() {
+					// The second migration was applied outside of Terraform.
+					db, err := sql.Open("sqlite", database)
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer db.Close()
+					if _, err := db.Exec("CREATE TABLE payments (id INTEGER PRIMARY KEY, order_id INTEGER)"); err != nil {
+						t.Fatal(err)
+					}
+					if _, err := db.Exec("INSERT INTO goose_db_version (version_id, is_applied) VALUES (2, 1)"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				// The state is at the target, but the database has to roll back the second migration.
+				Config:      testConfig(database, migrationsDir, "target_version = 1"),
+				ExpectError: regexp.MustCompile("Destructive migration statements"),
+			},
+			{
+				// max_steps counts from the version of the database, not from the state.
+				Config: testConfig(database, migrationsDir, "target_version = 0\n  max_steps = 1\n  allow_destructive = true"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "version", "1"),
+					resource.TestCheckResourceAttr("goose_ydb_migration.db", "pending_down.#", "1"),
+					checkTables(database, "orders"),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccMigrationFailedMigration(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config:      testConfig(database, migrationsDir, ""),
+				ExpectError: regexp.MustCompile("Failed to migrate"),
+			},
+		},
+	})
+}
+
+func TestAccMigrationFailedToken(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "test.db")
+	migrationsDir, err := filepath.Abs("testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Err: errors.New("token expired")})),
+		Steps: []resource.TestStep{
+			{
+				Config:      testConfig(database, migrationsDir, ""),
+				ExpectError: regexp.MustCompile("token expired"),
+			},
+		},
+	})
+}
@@ -0,0 +1,6 @@
+-- +goose Up
+CREATE TABLE orders (id INTEGER PRIMARY KEY);
+CREATE TABL payments (id INTEGER PRIMARY KEY);
+
+-- +goose Down
+DROP TABLE orders;
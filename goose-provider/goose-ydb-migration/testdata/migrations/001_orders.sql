@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE orders (id INTEGER PRIMARY KEY, amount INTEGER);
+
+-- +goose Down
+DROP TABLE orders;
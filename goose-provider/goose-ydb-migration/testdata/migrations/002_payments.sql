@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE payments (id INTEGER PRIMARY KEY, order_id INTEGER);
+
+-- +goose Down
+DROP TABLE payments;
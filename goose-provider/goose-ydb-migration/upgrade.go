@@ -0,0 +1,110 @@
+package goose_ydb_migration
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// schemaVersion is the version of the current resource schema. Every change of the schema
+// which cannot be read from an older state must bump it and add an upgrader to UpgradeState.
+const schemaVersion = 1
+
+// ydbMigrationDataModelV0 is the state of the resource before the schema was versioned.
+type ydbMigrationDataModelV0 struct {
+	Endpoint       types.String   `tfsdk:"endpoint"`
+	Database       types.String   `tfsdk:"database"`
+	TlsEnabled     types.Bool     `tfsdk:"tls_enabled"`
+	MigrationTable types.String   `tfsdk:"migration_table"`
+	MigrationsDir  types.String   `tfsdk:"migrations_dir"`
+	Version        types.Int64    `tfsdk:"version"`
+	TargetVersion  types.Int64    `tfsdk:"target_version"`
+	Migrations     types.List     `tfsdk:"migrations"`
+	Timeouts       timeouts.Value `tfsdk:"timeouts"`
+}
+
+func schemaV0(ctx context.Context) *schema.Schema {
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Required: true,
+			},
+			"database": schema.StringAttribute{
+				Required: true,
+			},
+			"tls_enabled": schema.BoolAttribute{
+				Optional: true,
+			},
+			"migration_table": schema.StringAttribute{
+				Optional: true,
+			},
+			"migrations_dir": schema.StringAttribute{
+				Required: true,
+			},
+			"version": schema.Int64Attribute{
+				Computed: true,
+			},
+			"target_version": schema.Int64Attribute{
+				Optional: true,
+			},
+			"migrations": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (y *ydbMigration) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   schemaV0(ctx),
+			StateUpgrader: upgradeStateV0,
+		},
+	}
+}
+
+func upgradeStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior ydbMigrationDataModelV0
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	upgraded := ydbMigrationDataModel{
+		Endpoint:              prior.Endpoint,
+		Database:              prior.Database,
+		TlsEnabled:            prior.TlsEnabled,
+		MigrationTable:        prior.MigrationTable,
+		DropOldTable:          types.BoolNull(),
+		MigrationsDir:         prior.MigrationsDir,
+		MigrationsDirs:        types.ListNull(types.StringType),
+		Version:               prior.Version,
+		TargetVersion:         prior.TargetVersion,
+		BaselineVersion:       types.Int64Null(),
+		MaxSteps:              types.Int64Null(),
+		AllowDestructive:      types.BoolNull(),
+		AllowIrreversibleSkip: types.BoolNull(),
+		DatabaseID:            types.StringNull(),
+		Migrations:            prior.Migrations,
+		RedoTrigger:           types.StringNull(),
+		RedoVersions:          types.Int64Null(),
+		RedoMigrations:        types.ListValueMust(types.StringType, nil),
+		PendingUp:             types.ListNull(types.StringType),
+		PendingDown:           types.ListNull(types.StringType),
+		LastApply:             types.ObjectNull(lastApplyAttrTypes),
+		History:               types.ListNull(historyType),
+		Timeouts:              prior.Timeouts,
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, upgraded)...)
+}
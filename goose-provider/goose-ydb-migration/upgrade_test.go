@@ -0,0 +1,86 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestUpgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	priorSchema := schemaV0(ctx)
+	priorType := priorSchema.Type().TerraformType(ctx).(tftypes.Object)
+
+	var schemaResp resource.SchemaResponse
+	(&ydbMigration{}).Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	currentSchema := schemaResp.Schema
+
+	req := resource.UpgradeStateRequest{
+		State: &tfsdk.State{
+			Schema: priorSchema,
+			Raw: tftypes.NewValue(priorType, map[string]tftypes.Value{
+				"endpoint":        tftypes.NewValue(tftypes.String, "ydb.serverless.yandexcloud.net:2135"),
+				"database":        tftypes.NewValue(tftypes.String, "/ru-central1/b1g/etn"),
+				"tls_enabled":     tftypes.NewValue(tftypes.Bool, nil),
+				"migration_table": tftypes.NewValue(tftypes.String, "schema_version"),
+				"migrations_dir":  tftypes.NewValue(tftypes.String, "migrations"),
+				"version":         tftypes.NewValue(tftypes.Number, 2),
+				"target_version":  tftypes.NewValue(tftypes.Number, nil),
+				"migrations": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, "migrations/01_orders.sql"),
+					tftypes.NewValue(tftypes.String, "migrations/02_payments.sql"),
+				}),
+				"timeouts": tftypes.NewValue(priorType.AttributeTypes["timeouts"], nil),
+			}),
+		},
+	}
+	resp := resource.UpgradeStateResponse{
+		State: tfsdk.State{
+			Schema: currentSchema,
+			Raw:    tftypes.NewValue(currentSchema.Type().TerraformType(ctx), nil),
+		},
+	}
+
+	upgradeStateV0(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("upgradeStateV0() diagnostics = %v", resp.Diagnostics)
+	}
+
+	var got ydbMigrationDataModel
+	if diags := resp.State.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("State.Get() diagnostics = %v", diags)
+	}
+	if got.MigrationsDir.ValueString() != "migrations" {
+		t.Errorf("migrations_dir = %v, want migrations", got.MigrationsDir)
+	}
+	if got.MigrationTable.ValueString() != "schema_version" {
+		t.Errorf("migration_table = %v, want schema_version", got.MigrationTable)
+	}
+	if got.Version.ValueInt64() != 2 {
+		t.Errorf("version = %v, want 2", got.Version)
+	}
+	if len(got.Migrations.Elements()) != 2 {
+		t.Errorf("migrations = %v, want 2 elements", got.Migrations)
+	}
+	if !got.MigrationsDirs.IsNull() || !got.RedoTrigger.IsNull() {
+		t.Errorf("new attributes must be null, got migrations_dirs = %v, redo_trigger = %v", got.MigrationsDirs, got.RedoTrigger)
+	}
+}
+
+func TestUpgradeStateVersions(t *testing.T) {
+	ctx := context.Background()
+	upgraders := (&ydbMigration{}).UpgradeState(ctx)
+	for version := int64(0); version < schemaVersion; version++ {
+		upgrader, ok := upgraders[version]
+		if !ok {
+			t.Errorf("no state upgrader for schema version %d", version)
+			continue
+		}
+		if upgrader.PriorSchema == nil {
+			t.Errorf("state upgrader for schema version %d has no prior schema", version)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"terraform-provider-goose/common"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// moveVersionTable creates the version table of store, copies the history of the version table of old
+// into it keeping the timestamps and drops the old table if requested. Every step can be rerun, so the next
+// apply completes a move which failed partway: an existing new table is kept, the copy replaces the records
+// copied before and the old table is only dropped once both tables hold the same number of records.
+//
+// The migration lock must be held. It only serializes the resources of this provider process; nothing protects
+// the move from another process, e.g. a second Terraform run or the command line, migrating the database meanwhile.
+func moveVersionTable(ctx context.Context, db *sql.DB, old, store *common.Store, dropOld bool) error {
+	from, to := old.Tablename(), store.Tablename()
+	if from == to {
+		return nil
+	}
+	if !old.HasVersionTable(ctx, db) {
+		// Either there is no history to move or an earlier apply dropped the old table after copying it.
+		tflog.Info(ctx, fmt.Sprintf("goose: version table %s doesn't exist, nothing to copy", from))
+		return nil
+	}
+
+	if store.HasVersionTable(ctx, db) {
+		if err := store.EnsureHistoryColumns(ctx, db); err != nil {
+			return err
+		}
+	} else {
+		tflog.Info(ctx, fmt.Sprintf("goose: creating version table %s", to))
+		if err := store.CreateVersionTable(ctx, db); err != nil {
+			return err
+		}
+	}
+	if err := old.EnsureHistoryColumns(ctx, db); err != nil {
+		return err
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("goose: copying version history from %s to %s", from, to))
+	if err := store.CopyHistory(ctx, db, old); err != nil {
+		return err
+	}
+	if !dropOld {
+		return nil
+	}
+
+	copied, err := store.CountRecords(ctx, db)
+	if err != nil {
+		return err
+	}
+	records, err := old.CountRecords(ctx, db)
+	if err != nil {
+		return err
+	}
+	if copied != records {
+		return fmt.Errorf("not dropping version table %q: it has %d records, but %q has %d", from, records, to, copied)
+	}
+	tflog.Info(ctx, fmt.Sprintf("goose: dropping version table %s", from))
+	return old.DropVersionTable(ctx, db)
+}
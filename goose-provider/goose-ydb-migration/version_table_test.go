@@ -0,0 +1,70 @@
+package goose_ydb_migration
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"terraform-provider-goose/common"
+
+	"github.com/pressly/goose/v3/database"
+	_ "modernc.org/sqlite"
+)
+
+func TestMoveVersionTableRetry(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	old, err := common.NewStore(database.DialectSQLite3, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := common.NewStore(database.DialectSQLite3, "schema_version", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.EnsureVersion(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range []int64{1, 2} {
+		if err := old.Insert(ctx, db, database.InsertRequest{Version: version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An earlier apply created the new table and failed while copying the history.
+	if err := store.CreateVersionTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_version (id, version_id, is_applied, tstamp) SELECT id, version_id, is_applied, tstamp FROM goose_db_version WHERE version_id < 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := moveVersionTable(ctx, db, old, store, true); err != nil {
+		t.Fatalf("moveVersionTable() after a failed copy: %v", err)
+	}
+	if old.HasVersionTable(ctx, db) {
+		t.Error("moveVersionTable() kept the old version table")
+	}
+	_, applied, err := store.AppliedVersions(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied versions = %v, want %v", applied, want)
+	}
+	if count, err := store.CountRecords(ctx, db); err != nil || count != 3 {
+		t.Errorf("CountRecords() = %d, %v, want 3 records without duplicates", count, err)
+	}
+
+	// The old table is gone once the move is done, so rerunning it changes nothing.
+	if err := moveVersionTable(ctx, db, old, store, true); err != nil {
+		t.Fatalf("moveVersionTable() after the move: %v", err)
+	}
+}
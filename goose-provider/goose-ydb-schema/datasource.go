@@ -0,0 +1,158 @@
+package goose_ydb_schema
+
+import (
+	"context"
+	"fmt"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/datasource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// dumpSchema renders the tables of the database as YQL. Tests replace it to read a fake scheme tree.
+var dumpSchema = common.DumpSchema
+
+type ydbSchema struct {
+	providerConfig *provider_config.Config
+}
+
+func NewDataSource() datasource.DataSource {
+	return &ydbSchema{}
+}
+
+func (y *ydbSchema) Metadata(_ context.Context, _ datasource.MetadataRequest, response *datasource.MetadataResponse) {
+	response.TypeName = "goose_ydb_schema"
+}
+
+func (y *ydbSchema) Schema(ctx context.Context, _ datasource.SchemaRequest, response *datasource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Description: "The current schema of a YDB database as `CREATE TABLE` YQL, e.g. to write a baseline migration or to review drift.",
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Optional: true,
+			},
+			"database": schema.StringAttribute{
+				Optional: true,
+			},
+			"database_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "ID of the YDB database. The endpoint, the database path and TLS are looked up in Yandex Cloud. Conflicts with `endpoint` and `database`.",
+			},
+			"tls_enabled": schema.BoolAttribute{
+				Optional: true,
+			},
+			"path": schema.StringAttribute{
+				Optional:    true,
+				Description: "Directory to dump, relative to the database. Defaults to the whole database.",
+			},
+			"migration_table": schema.StringAttribute{
+				Optional:    true,
+				Description: "Version table left out of the dump. Defaults to `goose_db_version`.",
+			},
+			"yql": schema.StringAttribute{
+				Computed:    true,
+				Description: "YQL of all the tables, ordered by path.",
+			},
+			"tables": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "YQL of every table keyed by its path relative to the database.",
+			},
+			"timeouts": timeouts.Attributes(ctx),
+		},
+	}
+}
+
+func (y *ydbSchema) ConfigValidators(_ context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.ExactlyOneOf(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("database"),
+		),
+		datasourcevalidator.RequiredTogether(
+			path.MatchRoot("endpoint"),
+			path.MatchRoot("database"),
+		),
+		datasourcevalidator.Conflicting(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("tls_enabled"),
+		),
+	}
+}
+
+func (y *ydbSchema) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerConfig, ok := req.ProviderData.(*provider_config.Config)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *provider_config.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	y.providerConfig = providerConfig
+}
+
+func (y *ydbSchema) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	tflog.Info(ctx, "Reading database schema")
+
+	var config ydbSchemaDataModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, timeoutInitError := config.Timeouts.Read(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	params, err := provider_config.ConnectionParamsFrom(ctx, y.providerConfig,
+		config.DatabaseID.ValueString(), config.Endpoint.ValueString(), config.Database.ValueString(), config.TlsEnabled.ValueBoolPointer())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
+		return
+	}
+	ctx, db, err := y.providerConfig.OpenDB(ctx, params)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to open DB", err.Error())
+		return
+	}
+	defer provider_config.CloseDB(ctx, db)
+
+	migrationTable := config.MigrationTable.ValueString()
+	if migrationTable == "" {
+		migrationTable = common.DefaultMigrationTable
+	}
+	tables, err := dumpSchema(ctx, db, config.Path.ValueString(), []string{migrationTable})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to dump schema", err.Error())
+		return
+	}
+
+	var diags diag.Diagnostics
+	config.YQL = types.StringValue(common.SchemaYQL(tables))
+	config.Tables, diags = types.MapValueFrom(ctx, types.StringType, tables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
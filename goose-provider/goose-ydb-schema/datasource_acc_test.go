@@ -0,0 +1,102 @@
+package goose_ydb_schema_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	goose_ydb_schema "terraform-provider-goose/goose-provider/goose-ydb-schema"
+	"terraform-provider-goose/goose-provider/internal/acctest"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/ydb-platform/ydb-go-sdk/v3/scheme"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/options"
+	"github.com/ydb-platform/ydb-go-sdk/v3/table/types"
+	_ "modernc.org/sqlite"
+)
+
+type fakeSchemeTree struct {
+	dirs   map[string][]scheme.Entry
+	tables map[string]options.Description
+}
+
+func (f fakeSchemeTree) ListDirectory(_ context.Context, path string) ([]scheme.Entry, error) {
+	return f.dirs[path], nil
+}
+
+func (f fakeSchemeTree) DescribeTable(_ context.Context, path string) (options.Description, error) {
+	return f.tables[path], nil
+}
+
+func testConfig(database string, extra string) string {
+	return fmt.Sprintf(`
+data "goose_ydb_schema" "db" {
+  endpoint = "localhost"
+  database = %q
+  %s
+}
+`, database, extra)
+}
+
+func TestAccSchema(t *testing.T) {
+	table := options.Description{
+		Columns:    []options.Column{{Name: "id", Type: types.TypeUint64}},
+		PrimaryKey: []string{"id"},
+	}
+	restore := goose_ydb_schema.SetSchemeTree(fakeSchemeTree{
+		dirs: map[string][]scheme.Entry{
+			"": {
+				{Name: "shop", Type: scheme.EntryDirectory},
+				{Name: "goose_db_version", Type: scheme.EntryTable},
+				{Name: "schema_version", Type: scheme.EntryTable},
+			},
+			"shop": {
+				{Name: "payments", Type: scheme.EntryTable},
+				{Name: "orders", Type: scheme.EntryTable},
+			},
+		},
+		tables: map[string]options.Description{"goose_db_version": table, "schema_version": table, "shop/orders": table, "shop/payments": table},
+	})
+	defer restore()
+
+	orders := "CREATE TABLE `shop/orders` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);"
+	payments := "CREATE TABLE `shop/payments` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);"
+	versions := "CREATE TABLE `schema_version` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);"
+	gooseVersions := "CREATE TABLE `goose_db_version` (\n    id Uint64 NOT NULL,\n    PRIMARY KEY (id)\n);"
+	database := filepath.Join(t.TempDir(), "test.db")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.%", "3"),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.shop/orders", orders),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.shop/payments", payments),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.schema_version", versions),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "yql", versions+"\n\n"+orders+"\n\n"+payments),
+				),
+			},
+			{
+				Config: testConfig(database, `path = "shop"
+  migration_table = "schema_version"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.%", "2"),
+					resource.TestCheckNoResourceAttr("data.goose_ydb_schema.db", "tables.schema_version"),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "yql", orders+"\n\n"+payments),
+				),
+			},
+			{
+				// Only the configured version table is left out.
+				Config: testConfig(database, `migration_table = "schema_version"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.%", "3"),
+					resource.TestCheckResourceAttr("data.goose_ydb_schema.db", "tables.goose_db_version", gooseVersions),
+					resource.TestCheckNoResourceAttr("data.goose_ydb_schema.db", "tables.schema_version"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,16 @@
+package goose_ydb_schema
+
+import (
+	"context"
+	"database/sql"
+
+	"terraform-provider-goose/common"
+)
+
+// SetSchemeTree makes the data source read tree instead of the database until restore is called.
+func SetSchemeTree(tree common.SchemeTree) (restore func()) {
+	dumpSchema = func(ctx context.Context, _ *sql.DB, dir string, exclude []string) (map[string]string, error) {
+		return common.DumpSchemeTree(ctx, tree, dir, exclude)
+	}
+	return func() { dumpSchema = common.DumpSchema }
+}
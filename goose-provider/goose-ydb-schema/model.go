@@ -0,0 +1,18 @@
+package goose_ydb_schema
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/datasource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ydbSchemaDataModel struct {
+	Endpoint       types.String   `tfsdk:"endpoint"`
+	Database       types.String   `tfsdk:"database"`
+	DatabaseID     types.String   `tfsdk:"database_id"`
+	TlsEnabled     types.Bool     `tfsdk:"tls_enabled"`
+	Path           types.String   `tfsdk:"path"`
+	MigrationTable types.String   `tfsdk:"migration_table"`
+	YQL            types.String   `tfsdk:"yql"`
+	Tables         types.Map      `tfsdk:"tables"`
+	Timeouts       timeouts.Value `tfsdk:"timeouts"`
+}
@@ -0,0 +1,22 @@
+package goose_ydb_seed
+
+import (
+	"context"
+	"time"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+const auditActionSeed = "seed"
+
+// audit appends a record of the seed run to the provider audit log.
+// A failure to write the record is reported as a warning: the seeds have already run.
+func (y *ydbSeed) audit(ctx context.Context, diags *diag.Diagnostics, params provider_config.ConnectionParams, started time.Time, results []common.MigrationResult, seedErr error) {
+	record := provider_config.NewAuditRecord(auditActionSeed, params, 0, 0, started, results, seedErr)
+	if err := y.providerConfig.Audit(ctx, record); err != nil {
+		diags.AddWarning("Failed to write audit log", err.Error())
+	}
+}
@@ -0,0 +1,16 @@
+package goose_ydb_seed
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type ydbSeedDataModel struct {
+	Endpoint   types.String   `tfsdk:"endpoint"`
+	Database   types.String   `tfsdk:"database"`
+	DatabaseID types.String   `tfsdk:"database_id"`
+	TlsEnabled types.Bool     `tfsdk:"tls_enabled"`
+	SeedsDir   types.String   `tfsdk:"seeds_dir"`
+	Checksums  types.Map      `tfsdk:"checksums"`
+	Timeouts   timeouts.Value `tfsdk:"timeouts"`
+}
@@ -0,0 +1,248 @@
+package goose_ydb_seed
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"terraform-provider-goose/common"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type ydbSeed struct {
+	providerConfig *provider_config.Config
+}
+
+func NewResource() resource.Resource {
+	return &ydbSeed{}
+}
+
+func (y *ydbSeed) Metadata(_ context.Context, _ resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = "goose_ydb_seed"
+}
+
+func (y *ydbSeed) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Description: "Idempotent data scripts which are re-run whenever their content changes. No version table is kept. " +
+			"Changing the database replaces the resource, so every script runs against the new one.",
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"database": schema.StringAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"database_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "ID of the YDB database. The endpoint, the database path and TLS are looked up in Yandex Cloud. Conflicts with `endpoint` and `database`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"tls_enabled": schema.BoolAttribute{
+				Optional: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"seeds_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "Directory of goose-style SQL scripts, e.g. `001_currencies.sql`. They are run in version order.",
+				Validators: []validator.String{
+					common.DirValidator{},
+				},
+			},
+			"checksums": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "sha256 checksums of the applied scripts keyed by file name.",
+				PlanModifiers: []planmodifier.Map{
+					common.SeedChecksumsPlanModifier(),
+				},
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+		},
+	}
+}
+
+func (y *ydbSeed) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("database"),
+		),
+		resourcevalidator.RequiredTogether(
+			path.MatchRoot("endpoint"),
+			path.MatchRoot("database"),
+		),
+		resourcevalidator.Conflicting(
+			path.MatchRoot("database_id"),
+			path.MatchRoot("tls_enabled"),
+		),
+	}
+}
+
+func (y *ydbSeed) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	tflog.Info(ctx, "Creating seed resource")
+
+	var plannedSeed ydbSeedDataModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plannedSeed)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, timeoutInitError := plannedSeed.Timeouts.Create(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	resp.Diagnostics.Append(y.apply(ctx, &plannedSeed, map[string]string{})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plannedSeed)...)
+}
+
+// Read keeps the state as is: seeds leave no trace in the database to compare with.
+func (y *ydbSeed) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var stateSeed ydbSeedDataModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateSeed)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &stateSeed)...)
+}
+
+func (y *ydbSeed) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	tflog.Info(ctx, "Updating seed resource")
+
+	var planSeed, stateSeed ydbSeedDataModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planSeed)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateSeed)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, timeoutInitError := planSeed.Timeouts.Update(ctx, common.DefaultTimeout)
+	if timeoutInitError != nil {
+		resp.Diagnostics.Append(timeoutInitError...)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	applied := map[string]string{}
+	resp.Diagnostics.Append(stateSeed.Checksums.ElementsAs(ctx, &applied, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags := y.apply(ctx, &planSeed, applied)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		// Keep the checksums of the scripts which did run, so the next apply retries only the rest.
+		planSeed.Checksums, diags = types.MapValueFrom(ctx, types.StringType, applied)
+		resp.Diagnostics.Append(diags...)
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &planSeed)...)
+}
+
+// Delete only removes the resource from the state, the seeded data stays in the database.
+func (y *ydbSeed) Delete(ctx context.Context, _ resource.DeleteRequest, _ *resource.DeleteResponse) {
+	tflog.Info(ctx, "Deleting seed resource")
+}
+
+func (y *ydbSeed) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerConfig, ok := req.ProviderData.(*provider_config.Config)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider_config.Config, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	y.providerConfig = providerConfig
+}
+
+// apply runs the scripts of the planned seed whose checksum differs from the applied one.
+// applied is updated with the checksum of every script which ran successfully.
+func (y *ydbSeed) apply(ctx context.Context, plannedSeed *ydbSeedDataModel, applied map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	seeds, err := common.CollectSeeds(plannedSeed.SeedsDir.ValueString())
+	if err != nil {
+		diags.AddError("Failed to collect seeds", err.Error())
+		return diags
+	}
+	checksums := map[string]string{}
+	diags.Append(plannedSeed.Checksums.ElementsAs(ctx, &checksums, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	changed := common.ChangedSeeds(seeds, checksums, applied)
+	if len(changed) == 0 {
+		return diags
+	}
+
+	params, err := provider_config.ConnectionParamsFrom(ctx, y.providerConfig,
+		plannedSeed.DatabaseID.ValueString(), plannedSeed.Endpoint.ValueString(), plannedSeed.Database.ValueString(), plannedSeed.TlsEnabled.ValueBoolPointer())
+	if err != nil {
+		diags.AddError("Failed to open DB", err.Error())
+		return diags
+	}
+	ctx, db, err := y.providerConfig.OpenDB(ctx, params)
+	if err != nil {
+		diags.AddError("Failed to open DB", err.Error())
+		return diags
+	}
+	defer provider_config.CloseDB(ctx, db)
+
+	started := time.Now()
+	results, err := common.ApplySeeds(ctx, db, y.providerConfig.Dialect, plannedSeed.SeedsDir.ValueString(), changed)
+	y.audit(ctx, &diags, params, started, results, err)
+	for _, result := range results {
+		name := filepath.Base(result.Source)
+		applied[name] = checksums[name]
+	}
+	if err != nil {
+		diags.AddError("Failed to seed", err.Error())
+	}
+	return diags
+}
@@ -0,0 +1,132 @@
+package goose_ydb_seed_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"terraform-provider-goose/goose-provider/internal/acctest"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	_ "modernc.org/sqlite"
+)
+
+func testConfig(database string, seedsDir string) string {
+	return fmt.Sprintf(`
+resource "goose_ydb_seed" "db" {
+  endpoint  = "localhost"
+  database  = %q
+  seeds_dir = %q
+}
+`, database, seedsDir)
+}
+
+func checkCurrencies(database string, want int) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		db, err := sql.Open("sqlite", database)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		var got int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM currencies`).Scan(&got); err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("currencies = %d rows, want %d", got, want)
+		}
+		return nil
+	}
+}
+
+// checkAudit checks the seed runs recorded in the audit log, one line per run with the files it ran.
+func checkAudit(auditLog string, want ...string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		content, err := os.ReadFile(auditLog)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if len(lines) != len(want) {
+			return fmt.Errorf("audit log has %d records, want %d", len(lines), len(want))
+		}
+		for i, line := range lines {
+			var record provider_config.AuditRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return err
+			}
+			if record.Action != "seed" || record.Outcome != provider_config.AuditOutcomeSuccess || record.Subject != "serviceAccount:ajesa" ||
+				len(record.Migrations) != 1 || record.Migrations[0].File != want[i] {
+				return fmt.Errorf("unexpected audit record %s", line)
+			}
+		}
+		return nil
+	}
+}
+
+func TestAccSeed(t *testing.T) {
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	config := acctest.Config(acctest.StaticTokenSource{Token: "t1.test"})
+	config.ProviderState.AuditLogPath = types.StringValue(auditLog)
+	config.Subject = "serviceAccount:ajesa"
+	database := filepath.Join(t.TempDir(), "test.db")
+	replacement := filepath.Join(t.TempDir(), "replacement.db")
+	seedsDir := t.TempDir()
+	content, err := os.ReadFile("testdata/seeds/001_currencies.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedFile := filepath.Join(seedsDir, "001_currencies.sql")
+	if err := os.WriteFile(seedFile, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: acctest.ProviderFactories(config),
+		Steps: []resource.TestStep{
+			{
+				Config: testConfig(database, seedsDir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("goose_ydb_seed.db", "checksums.%", "1"),
+					checkCurrencies(database, 2),
+					checkAudit(auditLog, "001_currencies.sql"),
+				),
+			},
+			{
+				PreConfig: func() {
+					extra := append(content, []byte("INSERT OR REPLACE INTO currencies VALUES ('GBP', 'Pound Sterling');\n")...)
+					if err := os.WriteFile(seedFile, extra, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testConfig(database, seedsDir),
+				Check: resource.ComposeTestCheckFunc(
+					checkCurrencies(database, 3),
+					checkAudit(auditLog, "001_currencies.sql", "001_currencies.sql"),
+				),
+			},
+			{
+				// Another database gets every script, not just the changed ones.
+				Config: testConfig(replacement, seedsDir),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("goose_ydb_seed.db", plancheck.ResourceActionReplace),
+					},
+				},
+				Check: resource.ComposeTestCheckFunc(
+					checkCurrencies(replacement, 3),
+					checkAudit(auditLog, "001_currencies.sql", "001_currencies.sql", "001_currencies.sql"),
+				),
+			},
+		},
+	})
+}
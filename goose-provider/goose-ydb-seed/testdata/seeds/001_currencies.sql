@@ -0,0 +1,4 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS currencies (code TEXT PRIMARY KEY, name TEXT);
+INSERT OR REPLACE INTO currencies VALUES ('EUR', 'Euro');
+INSERT OR REPLACE INTO currencies VALUES ('USD', 'US Dollar');
@@ -0,0 +1,48 @@
+// Package acctest runs the provider against local SQLite databases in tests.
+package acctest
+
+import (
+	"context"
+	"database/sql"
+
+	goose_provider "terraform-provider-goose/goose-provider"
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/pressly/goose/v3/database"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteOpener opens the `database` of the connection as the path of an SQLite database.
+type SQLiteOpener struct{}
+
+func (SQLiteOpener) OpenDB(_ context.Context, params provider_config.ConnectionParams) (*sql.DB, error) {
+	return sql.Open("sqlite", params.Database)
+}
+
+// StaticTokenSource issues Token, or fails with Err.
+type StaticTokenSource struct {
+	Token string
+	Err   error
+}
+
+func (s StaticTokenSource) IAMToken(_ context.Context) (string, error) {
+	return s.Token, s.Err
+}
+
+// Config returns a provider config which opens SQLite databases with the tokens of the token source.
+func Config(tokens provider_config.TokenSource) *provider_config.Config {
+	return &provider_config.Config{
+		TokenSource: tokens,
+		DBOpener:    SQLiteOpener{},
+		Dialect:     database.DialectSQLite3,
+	}
+}
+
+// ProviderFactories returns the factories of a provider configured with config.
+func ProviderFactories(config *provider_config.Config) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"goose": providerserver.NewProtocol6WithError(goose_provider.NewFrameworkProviderWithConfig(config)),
+	}
+}
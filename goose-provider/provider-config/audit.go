@@ -0,0 +1,124 @@
+package provider_config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"terraform-provider-goose/common"
+)
+
+// AuditRecord is a line of the audit log.
+type AuditRecord struct {
+	Time             time.Time        `json:"time"`
+	TerraformVersion string           `json:"terraform_version"`
+	Subject          string           `json:"subject"`
+	Action           string           `json:"action"`
+	Endpoint         string           `json:"endpoint,omitempty"`
+	Database         string           `json:"database,omitempty"`
+	DatabaseID       string           `json:"database_id,omitempty"`
+	FromVersion      int64            `json:"from_version"`
+	ToVersion        int64            `json:"to_version"`
+	Migrations       []AuditMigration `json:"migrations"`
+	DurationMs       int64            `json:"duration_ms"`
+	Outcome          string           `json:"outcome"`
+	Error            string           `json:"error,omitempty"`
+}
+
+// AuditMigration is a migration run by the audited action.
+type AuditMigration struct {
+	Version    int64  `json:"version"`
+	File       string `json:"file"`
+	Direction  string `json:"direction"`
+	Checksum   string `json:"checksum"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// NewAuditRecord returns the record of an action on the database described by params which started at started
+// and ran the migrations of results. A failed action is recorded with its error.
+func NewAuditRecord(action string, params ConnectionParams, from int64, to int64, started time.Time, results []common.MigrationResult, actionErr error) AuditRecord {
+	record := AuditRecord{
+		Time:        started.UTC(),
+		Action:      action,
+		Endpoint:    params.Endpoint,
+		Database:    params.Database,
+		DatabaseID:  params.DatabaseID,
+		FromVersion: from,
+		ToVersion:   to,
+		DurationMs:  time.Since(started).Milliseconds(),
+		Outcome:     AuditOutcomeSuccess,
+	}
+	if actionErr != nil {
+		record.Outcome = AuditOutcomeFailure
+		record.Error = actionErr.Error()
+	}
+	for _, result := range results {
+		// The file may be gone by the time it is rolled back on destroy.
+		checksum, _ := common.FileChecksum(result.Source)
+		record.Migrations = append(record.Migrations, AuditMigration{
+			Version:    result.Version,
+			File:       filepath.Base(result.Source),
+			Direction:  result.Direction(),
+			Checksum:   checksum,
+			DurationMs: result.Duration.Milliseconds(),
+		})
+	}
+	return record
+}
+
+// auditLock serializes the writes of resources applied in parallel.
+var auditLock sync.Mutex
+
+// Audit appends the record to audit_log_path as a JSON line. It does nothing if audit_log_path is not set.
+func (c *Config) Audit(ctx context.Context, record AuditRecord) error {
+	path := c.ProviderState.AuditLogPath.ValueString()
+	if path == "" {
+		return nil
+	}
+	record.TerraformVersion = terraformVersion(c.UserAgent.ValueString())
+	record.Subject = c.Subject
+	if c.subjectLookup != nil {
+		record.Subject = c.subjectLookup(ctx)
+	}
+	if record.Migrations == nil {
+		record.Migrations = []AuditMigration{}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// terraformVersion extracts the version from a `Terraform/1.7.0 (https://www.terraform.io) ...` user agent.
+func terraformVersion(userAgent string) string {
+	for _, part := range strings.Fields(userAgent) {
+		if version, ok := strings.CutPrefix(part, "Terraform/"); ok {
+			return version
+		}
+	}
+	return ""
+}
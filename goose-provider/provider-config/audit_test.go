@@ -0,0 +1,89 @@
+package provider_config
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"terraform-provider-goose/common"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	config := Config{
+		ProviderState: State{AuditLogPath: types.StringValue(path)},
+		UserAgent:     types.StringValue("Terraform/1.7.5 (https://www.terraform.io) goose/1.0.0"),
+		Subject:       "serviceAccount:ajesa",
+	}
+
+	for _, action := range []string{"up", "down"} {
+		err := config.Audit(context.Background(), AuditRecord{
+			Action:      action,
+			Database:    "/local/db",
+			FromVersion: 1,
+			ToVersion:   2,
+			Outcome:     AuditOutcomeSuccess,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("audit log has %d records, want 2", len(records))
+	}
+	if records[1].Action != "down" || records[1].TerraformVersion != "1.7.5" || records[1].Subject != "serviceAccount:ajesa" {
+		t.Errorf("audit record = %+v", records[1])
+	}
+}
+
+func TestAuditDisabled(t *testing.T) {
+	config := Config{}
+	if err := config.Audit(context.Background(), AuditRecord{Action: "up"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewAuditRecord(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "002_payments.sql")
+	if err := os.WriteFile(source, []byte("-- +goose Up\nCREATE TABLE payments (id INTEGER);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := common.FileChecksum(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := ConnectionParams{Endpoint: "ydb.serverless.yandexcloud.net:2135", Database: "/ru-central1/b1g/etn", DatabaseID: "etn"}
+	results := []common.MigrationResult{{Version: 2, Source: source, Up: true, Duration: 840 * time.Millisecond}}
+	record := NewAuditRecord("up", params, 1, 2, time.Now(), results, errors.New("boom"))
+	if record.Endpoint != params.Endpoint || record.Database != params.Database || record.DatabaseID != "etn" ||
+		record.FromVersion != 1 || record.ToVersion != 2 || record.Outcome != AuditOutcomeFailure || record.Error != "boom" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	want := AuditMigration{Version: 2, File: "002_payments.sql", Direction: "up", Checksum: checksum, DurationMs: 840}
+	if len(record.Migrations) != 1 || record.Migrations[0] != want {
+		t.Fatalf("unexpected migrations %+v, want %+v", record.Migrations, want)
+	}
+}
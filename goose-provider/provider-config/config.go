@@ -18,6 +18,7 @@ import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/mitchellh/go-homedir"
+	"github.com/pressly/goose/v3/database"
 	ycsdk "github.com/yandex-cloud/go-sdk"
 	"github.com/yandex-cloud/go-sdk/iamkey"
 	"github.com/yandex-cloud/go-sdk/pkg/requestid"
@@ -38,12 +39,25 @@ type State struct {
 	Token                          types.String `tfsdk:"token"`
 	ServiceAccountKeyFileOrContent types.String `tfsdk:"service_account_key_file"`
 	MaxRetries                     types.Int64  `tfsdk:"max_retries"`
+	Profile                        types.String `tfsdk:"profile"`
+	AuditLogPath                   types.String `tfsdk:"audit_log_path"`
 }
 
 type Config struct {
 	ProviderState State
 	UserAgent     types.String
 	SDK           *ycsdk.SDK
+	TokenSource   TokenSource
+	DBOpener      DBOpener
+	// DatabaseResolver resolves `database_id` to the connection details.
+	DatabaseResolver DatabaseResolver
+	// Subject describes who the IAM tokens are issued for, e.g. `serviceAccount:aje...`.
+	Subject string
+	// subjectLookup, if set, finds out the subject recorded in the audit log when it is first needed.
+	subjectLookup func(ctx context.Context) string
+	// Dialect is the SQL dialect of the version table. It is YDB unless the databases are opened
+	// by another DBOpener, e.g. SQLite in tests.
+	Dialect database.Dialect
 }
 
 func (c *Config) InitAndValidate(ctx context.Context, terraformVersion string, sweeper bool) error {
@@ -98,8 +112,14 @@ func (c *Config) InitAndValidate(ctx context.Context, terraformVersion string, s
 		grpc.WithUserAgent(c.UserAgent.ValueString()),
 		grpc.WithDefaultCallOptions(grpc.Header(&headerMD)),
 		grpc.WithUnaryInterceptor(interceptorChain))
+	if err != nil {
+		return err
+	}
 
-	return err
+	c.TokenSource = sdkTokenSource{sdk: c.SDK}
+	c.DBOpener = YDBOpener{}
+	c.DatabaseResolver = sdkDatabaseResolver{sdk: c.SDK}
+	return nil
 }
 
 func (c *Config) Credentials(ctx context.Context) (ycsdk.Credentials, error) {
@@ -113,29 +133,47 @@ func (c *Config) Credentials(ctx context.Context) (ycsdk.Credentials, error) {
 		if err != nil {
 			return nil, err
 		}
+		c.Subject = "serviceAccount:" + key.GetServiceAccountId()
 		return ycsdk.ServiceAccountKey(key)
 	}
 
 	if c.ProviderState.Token.ValueString() != "" {
-		if strings.HasPrefix(
-			c.ProviderState.Token.ValueString(), "t1.",
-		) && strings.Count(
-			c.ProviderState.Token.ValueString(), ".",
-		) == 2 {
-			return ycsdk.NewIAMTokenCredentials(c.ProviderState.Token.ValueString()), nil
+		c.Subject = tokenSubject(c.ProviderState.Token.ValueString())
+		return tokenCredentials(c.ProviderState.Token.ValueString()), nil
+	}
+
+	if c.ProviderState.Profile.ValueString() != "" {
+		path, err := DefaultYCConfigPath()
+		if err != nil {
+			return nil, err
 		}
-		return ycsdk.OAuthToken(c.ProviderState.Token.ValueString()), nil
+		profile, err := LoadYCProfile(path, c.ProviderState.Profile.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		c.Subject = profile.Subject()
+		return profile.Credentials(ctx)
 	}
 
 	if sa := ycsdk.InstanceServiceAccount(); checkServiceAccountAvailable(ctx, sa) {
+		c.Subject = instanceSubjectFallback
+		c.subjectLookup = c.instanceSubjectLookup()
 		return sa, nil
 	}
 
-	return nil, fmt.Errorf("one of 'token' or 'service_account_key_file' should be specified;" +
+	return nil, fmt.Errorf("one of 'token', 'service_account_key_file' or 'profile' should be specified;" +
 		" if you are inside compute instance, you can attach service account to it in order to " +
 		"authenticate via instance service account")
 }
 
+// tokenCredentials treats `t1.*.*` tokens as IAM tokens and the rest as OAuth tokens.
+func tokenCredentials(token string) ycsdk.Credentials {
+	if isIAMToken(token) {
+		return ycsdk.NewIAMTokenCredentials(token)
+	}
+	return ycsdk.OAuthToken(token)
+}
+
 func iamKeyFromJSONContent(content string) (*iamkey.Key, error) {
 	key := &iamkey.Key{}
 	err := json.Unmarshal([]byte(content), key)
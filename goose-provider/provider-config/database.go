@@ -0,0 +1,80 @@
+package provider_config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/yandex-cloud/go-genproto/yandex/cloud/ydb/v1"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+)
+
+// DatabaseResolver looks up the connection details of a YDB database by its ID.
+type DatabaseResolver interface {
+	ResolveDatabase(ctx context.Context, databaseID string) (ConnectionParams, error)
+}
+
+type sdkDatabaseResolver struct {
+	sdk *ycsdk.SDK
+}
+
+func (r sdkDatabaseResolver) ResolveDatabase(ctx context.Context, databaseID string) (ConnectionParams, error) {
+	database, err := r.sdk.YDB().Database().Get(ctx, &ydb.GetDatabaseRequest{DatabaseId: databaseID})
+	if err != nil {
+		return ConnectionParams{}, fmt.Errorf("failed to get database %s: %w", databaseID, err)
+	}
+	if database.Status != ydb.Database_RUNNING {
+		return ConnectionParams{}, fmt.Errorf("database %s (%s) is %s, it must be RUNNING to be migrated", databaseID, database.Name, database.Status)
+	}
+	return ParseDatabaseEndpoint(database.Endpoint)
+}
+
+// ResolveDatabase returns the connection details of the database with the given ID.
+func (c *Config) ResolveDatabase(ctx context.Context, databaseID string) (ConnectionParams, error) {
+	if c.DatabaseResolver == nil {
+		return ConnectionParams{}, errors.New("database_id can't be resolved without the Yandex Cloud SDK")
+	}
+	return c.DatabaseResolver.ResolveDatabase(ctx, databaseID)
+}
+
+// ConnectionParamsFrom returns the connection details of a resource: the ones of databaseID if it is set,
+// the endpoint, the database and TLS as configured otherwise.
+func ConnectionParamsFrom(ctx context.Context, config *Config, databaseID string, endpoint string, database string, tlsEnabled *bool) (ConnectionParams, error) {
+	if databaseID != "" {
+		params, err := config.ResolveDatabase(ctx, databaseID)
+		params.DatabaseID = databaseID
+		return params, err
+	}
+	return ConnectionParams{
+		Endpoint:   endpoint,
+		Database:   database,
+		TLSEnabled: tlsEnabled,
+	}, nil
+}
+
+// ParseDatabaseEndpoint splits a full YDB endpoint like
+// `grpcs://ydb.serverless.yandexcloud.net:2135/?database=/ru-central1/b1g/etn` into its parts.
+func ParseDatabaseEndpoint(endpoint string) (ConnectionParams, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ConnectionParams{}, fmt.Errorf("cannot parse endpoint %q: %w", endpoint, err)
+	}
+	var tlsEnabled bool
+	switch u.Scheme {
+	case "grpcs":
+		tlsEnabled = true
+	case "grpc":
+	default:
+		return ConnectionParams{}, fmt.Errorf("cannot parse endpoint %q: unknown scheme %q", endpoint, u.Scheme)
+	}
+	database := u.Query().Get("database")
+	if u.Host == "" || database == "" {
+		return ConnectionParams{}, fmt.Errorf("cannot parse endpoint %q: expected <scheme>://<host>/?database=<path>", endpoint)
+	}
+	return ConnectionParams{
+		Endpoint:   u.Host,
+		Database:   database,
+		TLSEnabled: &tlsEnabled,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package provider_config
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseDatabaseEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     ConnectionParams
+		tls      bool
+		wantErr  bool
+	}{
+		{
+			name:     "serverless",
+			endpoint: "grpcs://ydb.serverless.yandexcloud.net:2135/?database=/ru-central1/b1g/etn",
+			want:     ConnectionParams{Endpoint: "ydb.serverless.yandexcloud.net:2135", Database: "/ru-central1/b1g/etn"},
+			tls:      true,
+		},
+		{
+			name:     "without TLS",
+			endpoint: "grpc://lb.etn.ydb.mdb.yandexcloud.net:2136/?database=/ru-central1/b1g/etn",
+			want:     ConnectionParams{Endpoint: "lb.etn.ydb.mdb.yandexcloud.net:2136", Database: "/ru-central1/b1g/etn"},
+		},
+		{
+			name:     "no database",
+			endpoint: "grpcs://ydb.serverless.yandexcloud.net:2135",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown scheme",
+			endpoint: "https://ydb.serverless.yandexcloud.net:2135/?database=/ru-central1/b1g/etn",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDatabaseEndpoint(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDatabaseEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Endpoint != tt.want.Endpoint || got.Database != tt.want.Database || *got.TLSEnabled != tt.tls {
+				t.Errorf("ParseDatabaseEndpoint() = %+v (tls %v), want %+v (tls %v)", got, *got.TLSEnabled, tt.want, tt.tls)
+			}
+		})
+	}
+}
+
+type testDatabaseResolver map[string]string
+
+func (r testDatabaseResolver) ResolveDatabase(_ context.Context, databaseID string) (ConnectionParams, error) {
+	return ParseDatabaseEndpoint(r[databaseID])
+}
+
+func TestConnectionParamsFrom(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{DatabaseResolver: testDatabaseResolver{
+		"etn": "grpcs://ydb.serverless.yandexcloud.net:2135/?database=/ru-central1/b1g/etn",
+	}}
+
+	got, err := ConnectionParamsFrom(ctx, config, "etn", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Endpoint != "ydb.serverless.yandexcloud.net:2135" || got.Database != "/ru-central1/b1g/etn" || got.DatabaseID != "etn" || !*got.TLSEnabled {
+		t.Errorf("ConnectionParamsFrom() of a database ID = %+v", got)
+	}
+
+	tls := false
+	got, err = ConnectionParamsFrom(ctx, config, "", "localhost:2136", "/local", &tls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Endpoint != "localhost:2136" || got.Database != "/local" || got.DatabaseID != "" || *got.TLSEnabled {
+		t.Errorf("ConnectionParamsFrom() of an endpoint = %+v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package provider_config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/pressly/goose/v3"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+	_ "github.com/ydb-platform/ydb-go-sdk/v3"
+)
+
+// TokenSource issues the IAM token used to authenticate database connections.
+type TokenSource interface {
+	IAMToken(ctx context.Context) (string, error)
+}
+
+// DBOpener opens the database the migrations are applied to.
+type DBOpener interface {
+	OpenDB(ctx context.Context, params ConnectionParams) (*sql.DB, error)
+}
+
+type ConnectionParams struct {
+	Endpoint string
+	Database string
+	// DatabaseID is the ID the endpoint and the database were resolved from, if any.
+	DatabaseID string
+	Token      string
+	TLSEnabled *bool
+}
+
+type sdkTokenSource struct {
+	sdk *ycsdk.SDK
+}
+
+func (s sdkTokenSource) IAMToken(ctx context.Context) (string, error) {
+	iamToken, err := s.sdk.CreateIAMToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return iamToken.IamToken, nil
+}
+
+// YDBOpener opens YDB databases with the goose ydb driver.
+type YDBOpener struct{}
+
+func (YDBOpener) OpenDB(_ context.Context, params ConnectionParams) (*sql.DB, error) {
+	dbString := makeDbString(params.Endpoint, params.Database, params.Token, params.TLSEnabled)
+	return goose.OpenDBWithDriver("ydb", dbString)
+}
+
+// OpenDB issues an IAM token and opens the database with it.
+// The returned context masks the token in log messages.
+func (c *Config) OpenDB(ctx context.Context, params ConnectionParams) (context.Context, *sql.DB, error) {
+	ctx, token, err := c.IAMToken(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	params.Token = token
+
+	db, err := c.OpenDBWithToken(ctx, params)
+	return ctx, db, err
+}
+
+// IAMToken issues an IAM token to open several databases with.
+// The returned context masks the token in log messages.
+func (c *Config) IAMToken(ctx context.Context) (context.Context, string, error) {
+	token, err := c.TokenSource.IAMToken(ctx)
+	if err != nil {
+		return ctx, "", fmt.Errorf("failed to create IAM token: %w", err)
+	}
+	if token != "" {
+		ctx = tflog.MaskMessageStrings(ctx, token)
+	}
+	return ctx, token, nil
+}
+
+// OpenDBWithToken opens the database with the token of the params.
+func (c *Config) OpenDBWithToken(ctx context.Context, params ConnectionParams) (*sql.DB, error) {
+	db, err := c.DBOpener.OpenDB(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+	return db, nil
+}
+
+func makeDbString(endpoint string, database string, token string, tlsEnabled *bool) string {
+	tls := "grpcs"
+	if tlsEnabled != nil && !*tlsEnabled {
+		tls = "grpc"
+	}
+	options := map[string]string{
+		"go_query_bind": "declare,numeric",
+		"token":         token,
+	}
+	q := strings.Builder{}
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if q.Len() > 0 {
+			q.WriteByte('&')
+		}
+		q.WriteString(k)
+		q.WriteByte('=')
+		q.WriteString(options[k])
+	}
+
+	return fmt.Sprintf("%s://%s%s?%s", tls, endpoint, database, q.String())
+}
+
+// CloseDB closes the database and logs the error, if any.
+func CloseDB(ctx context.Context, db *sql.DB) {
+	if err := db.Close(); err != nil {
+		tflog.Error(ctx, fmt.Sprintf("goose: failed to close DB: %v", err))
+	}
+}
@@ -1,4 +1,4 @@
-package goose_ydb_migration
+package provider_config
 
 import "testing"
 
@@ -22,7 +22,7 @@ func Test_makeDbString(t *testing.T) {
 				token:      "token",
 				tlsEnabled: true,
 			},
-			want: "grpcs://endpoint/database?go_fake_tx=scripting&go_query_bind=declare,numeric&go_query_mode=scripting&token=token",
+			want: "grpcs://endpoint/database?go_query_bind=declare,numeric&token=token",
 		},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,106 @@
+package provider_config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/mitchellh/go-homedir"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+)
+
+// YCProfile is a profile of the yc CLI configuration.
+type YCProfile struct {
+	Name              string          `json:"-"`
+	Token             string          `json:"token"`
+	ServiceAccountKey json.RawMessage `json:"service-account-key"`
+	Endpoint          string          `json:"endpoint"`
+	FederationID      string          `json:"federation-id"`
+}
+
+type ycConfig struct {
+	Profiles map[string]YCProfile `json:"profiles"`
+}
+
+// DefaultYCConfigPath returns the path of the yc CLI configuration, `~/.config/yandex-cloud/config.yaml`.
+func DefaultYCConfigPath() (string, error) {
+	return homedir.Expand(filepath.Join("~", ".config", "yandex-cloud", "config.yaml"))
+}
+
+// LoadYCProfile reads the named profile from the yc CLI configuration file.
+func LoadYCProfile(path string, name string) (*YCProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read yc config: %w", err)
+	}
+	var config ycConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse yc config %s: %w", path, err)
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	profile.Name = name
+	return &profile, nil
+}
+
+// ProfileEndpoint returns the API endpoint of the named yc profile, or defaultEndpoint if the profile has none.
+func ProfileEndpoint(name string, defaultEndpoint string) string {
+	if name == "" {
+		return defaultEndpoint
+	}
+	path, err := DefaultYCConfigPath()
+	if err != nil {
+		return defaultEndpoint
+	}
+	profile, err := LoadYCProfile(path, name)
+	if err != nil || profile.Endpoint == "" {
+		return defaultEndpoint
+	}
+	return profile.Endpoint
+}
+
+// Subject describes who the credentials of the profile belong to.
+func (p *YCProfile) Subject() string {
+	switch {
+	case len(p.ServiceAccountKey) > 0:
+		var key struct {
+			ServiceAccountID string `json:"service_account_id"`
+		}
+		_ = json.Unmarshal(p.ServiceAccountKey, &key)
+		return "serviceAccount:" + key.ServiceAccountID
+	case p.Token != "":
+		return tokenSubject(p.Token)
+	case p.FederationID != "":
+		return "federation:" + p.FederationID
+	}
+	return "profile:" + p.Name
+}
+
+// Credentials returns the credentials of the profile: its service account key, its token,
+// or, for a federation profile, an IAM token issued by the yc CLI itself.
+func (p *YCProfile) Credentials(ctx context.Context) (ycsdk.Credentials, error) {
+	switch {
+	case len(p.ServiceAccountKey) > 0:
+		key, err := iamKeyFromJSONContent(string(p.ServiceAccountKey))
+		if err != nil {
+			return nil, err
+		}
+		return ycsdk.ServiceAccountKey(key)
+	case p.Token != "":
+		return tokenCredentials(p.Token), nil
+	case p.FederationID != "":
+		out, err := exec.CommandContext(ctx, "yc", "iam", "create-token", "--profile", p.Name).Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create IAM token for federation profile %q with yc: %w", p.Name, err)
+		}
+		return ycsdk.NewIAMTokenCredentials(strings.TrimSpace(string(out))), nil
+	}
+	return nil, fmt.Errorf("profile %q has none of token, service-account-key or federation-id", p.Name)
+}
@@ -0,0 +1,64 @@
+package provider_config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testYCConfig = `current: default
+profiles:
+  default:
+    token: AQAAAAAtest
+    cloud-id: b1g
+    folder-id: b1g
+  sa:
+    service-account-key:
+      id: ajeid
+      service_account_id: ajesa
+      key_algorithm: RSA_2048
+      private_key: |
+        PLEASE DO NOT REMOVE THIS LINE!
+    endpoint: api.example.net:443
+  fed:
+    federation-id: bpfid
+`
+
+func TestLoadYCProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testYCConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadYCProfile(path, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Token != "AQAAAAAtest" || profile.Endpoint != "" {
+		t.Errorf("LoadYCProfile(default) = %+v", profile)
+	}
+
+	profile, err = LoadYCProfile(path, "sa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := iamKeyFromJSONContent(string(profile.ServiceAccountKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Id != "ajeid" || key.GetServiceAccountId() != "ajesa" || profile.Endpoint != "api.example.net:443" {
+		t.Errorf("LoadYCProfile(sa) = %+v, key %+v", profile, key)
+	}
+
+	profile, err = LoadYCProfile(path, "fed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.FederationID != "bpfid" || profile.Name != "fed" {
+		t.Errorf("LoadYCProfile(fed) = %+v", profile)
+	}
+
+	if _, err := LoadYCProfile(path, "missing"); err == nil {
+		t.Error("LoadYCProfile(missing) error = nil")
+	}
+}
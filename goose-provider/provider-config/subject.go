@@ -0,0 +1,98 @@
+package provider_config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	ycsdk "github.com/yandex-cloud/go-sdk"
+)
+
+// instanceServiceAccountURL returns the ID of the service account attached to the compute instance.
+var instanceServiceAccountURL = fmt.Sprintf("http://%s/computeMetadata/v1/instance/service-accounts/default/email", ycsdk.InstanceMetadataAddr)
+
+const (
+	subjectLookupTimeout = 5 * time.Second
+	// instanceSubjectFallback is the subject of the service account of the instance until, or unless, its ID is known.
+	instanceSubjectFallback = "instanceServiceAccount"
+)
+
+// tokenSubject identifies the token by a fingerprint: a token doesn't name who it is issued for,
+// but the fingerprint matches the same token in other records without recording the token itself.
+func tokenSubject(token string) string {
+	if isIAMToken(token) {
+		return "iamToken:" + fingerprint(token)
+	}
+	return "oauthToken:" + fingerprint(token)
+}
+
+func isIAMToken(token string) bool {
+	return strings.HasPrefix(token, "t1.") && strings.Count(token, ".") == 2
+}
+
+// fingerprint returns the first 12 hex digits of the sha256 of the secret.
+func fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// instanceSubjectLookup returns a lookup of the service account attached to the compute instance, which is
+// only asked from the metadata service the first time the subject is needed. If the metadata service doesn't
+// answer, the subject is the fingerprint of an IAM token of the service account.
+func (c *Config) instanceSubjectLookup() func(ctx context.Context) string {
+	var once sync.Once
+	var subject string
+	return func(ctx context.Context) string {
+		once.Do(func() {
+			var err error
+			if subject, err = instanceSubject(ctx); err == nil {
+				return
+			}
+			tflog.Warn(ctx, fmt.Sprintf("goose: failed to read the service account of the instance: %v", err))
+			subject = instanceSubjectFallback
+			if c.TokenSource == nil {
+				return
+			}
+			if token, err := c.TokenSource.IAMToken(ctx); err == nil {
+				subject = tokenSubject(token)
+			}
+		})
+		return subject
+	}
+}
+
+// instanceSubject returns the service account attached to the compute instance as reported by its metadata service.
+func instanceSubject(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, subjectLookupTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, instanceServiceAccountURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", instanceServiceAccountURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(string(body))
+	if id == "" {
+		return "", fmt.Errorf("the metadata service reported no service account")
+	}
+	return "serviceAccount:" + id, nil
+}
@@ -0,0 +1,67 @@
+package provider_config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testTokenSource struct {
+	token string
+	err   error
+}
+
+func (s testTokenSource) IAMToken(_ context.Context) (string, error) {
+	return s.token, s.err
+}
+
+func TestTokenSubject(t *testing.T) {
+	if subject := tokenSubject("AQAAAAAtest"); subject != "oauthToken:"+fingerprint("AQAAAAAtest") {
+		t.Fatalf("unexpected subject of the OAuth token: %s", subject)
+	}
+	iam := tokenSubject("t1.abc.def")
+	if iam != "iamToken:"+fingerprint("t1.abc.def") || iam == tokenSubject("t1.abc.xyz") {
+		t.Fatalf("unexpected subject of the IAM token: %s", iam)
+	}
+}
+
+func TestInstanceSubjectLookup(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("ajesa\n"))
+	}))
+	defer server.Close()
+	defer func(url string) { instanceServiceAccountURL = url }(instanceServiceAccountURL)
+	instanceServiceAccountURL = server.URL
+
+	ctx := context.Background()
+	lookup := (&Config{}).instanceSubjectLookup()
+	if requests != 0 {
+		t.Fatal("the metadata service is asked before the subject is needed")
+	}
+	for i := 0; i < 2; i++ {
+		if subject := lookup(ctx); subject != "serviceAccount:ajesa" {
+			t.Fatalf("unexpected subject of the instance: %s", subject)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("the metadata service is asked %d times, want once", requests)
+	}
+
+	server.Close()
+	lookup = (&Config{TokenSource: testTokenSource{token: "t1.abc.def"}}).instanceSubjectLookup()
+	if subject := lookup(ctx); subject != "iamToken:"+fingerprint("t1.abc.def") {
+		t.Fatalf("unexpected subject without the metadata service: %s", subject)
+	}
+	lookup = (&Config{TokenSource: testTokenSource{err: errors.New("no token")}}).instanceSubjectLookup()
+	if subject := lookup(ctx); subject != instanceSubjectFallback {
+		t.Fatalf("unexpected subject without a token: %s", subject)
+	}
+}
@@ -7,11 +7,16 @@ import (
 	"os"
 
 	"terraform-provider-goose/common"
+	goose_functions "terraform-provider-goose/goose-provider/goose-functions"
 	goose_ydb_migration "terraform-provider-goose/goose-provider/goose-ydb-migration"
+	goose_ydb_migration_fleet "terraform-provider-goose/goose-provider/goose-ydb-migration-fleet"
+	goose_ydb_schema "terraform-provider-goose/goose-provider/goose-ydb-schema"
+	goose_ydb_seed "terraform-provider-goose/goose-provider/goose-ydb-seed"
 	"terraform-provider-goose/goose-provider/provider-config"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/providervalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -21,8 +26,9 @@ import (
 )
 
 type Provider struct {
-	emptyFolder bool
-	config      provider_config.Config
+	emptyFolder   bool
+	config        provider_config.Config
+	preconfigured *provider_config.Config
 }
 
 func (p Provider) Metadata(_ context.Context, _ provider.MetadataRequest, response *provider.MetadataResponse) {
@@ -48,6 +54,14 @@ func (p Provider) Schema(_ context.Context, _ provider.SchemaRequest, response *
 					saKeyValidator{},
 				},
 			},
+			"profile": schema.StringAttribute{
+				Optional:    true,
+				Description: common.Descriptions["profile"],
+			},
+			"audit_log_path": schema.StringAttribute{
+				Optional:    true,
+				Description: common.Descriptions["audit_log_path"],
+			},
 			"max_retries": schema.Int64Attribute{
 				Optional:    true,
 				Description: common.Descriptions["max_retries"],
@@ -57,10 +71,16 @@ func (p Provider) Schema(_ context.Context, _ provider.SchemaRequest, response *
 }
 
 func (p Provider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	if p.preconfigured != nil {
+		resp.ResourceData = p.preconfigured
+		resp.DataSourceData = p.preconfigured
+		return
+	}
+
 	p.config = provider_config.Config{}
 	resp.Diagnostics.Append(req.Config.Get(ctx, &p.config.ProviderState)...)
 	p.config.UserAgent = types.StringValue(req.TerraformVersion)
-	p.config.ProviderState = setDefaults(p.config.ProviderState)
+	p.config.ProviderState = SetDefaults(p.config.ProviderState)
 
 	if err := p.config.InitAndValidate(ctx, req.TerraformVersion, false); err != nil {
 		resp.Diagnostics.AddError("Failed to configure", err.Error())
@@ -74,24 +94,39 @@ func (p Provider) ConfigValidators(_ context.Context) []provider.ConfigValidator
 		providervalidator.Conflicting(
 			path.MatchRoot("token"),
 			path.MatchRoot("service_account_key_file"),
+			path.MatchRoot("profile"),
 		),
 	}
 }
 
 func (p Provider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		goose_ydb_schema.NewDataSource,
+	}
 }
 
 func (p Provider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		goose_ydb_migration.NewResource,
+		goose_ydb_migration_fleet.NewResource,
+		goose_ydb_seed.NewResource,
 	}
 }
 
+func (p Provider) Functions(_ context.Context) []func() function.Function {
+	return goose_functions.Functions()
+}
+
 func NewFrameworkProvider() provider.Provider {
 	return &Provider{}
 }
 
+// NewFrameworkProviderWithConfig returns a provider which ignores the provider block and passes
+// the given config to its resources. It allows running the resources against a local database.
+func NewFrameworkProviderWithConfig(config *provider_config.Config) provider.Provider {
+	return &Provider{preconfigured: config}
+}
+
 type saKeyValidator struct{}
 
 func (v saKeyValidator) Description(_ context.Context) string {
@@ -131,10 +166,15 @@ func setToDefaultIfNeeded(field types.String, osEnvName string, defaultVal strin
 	return field
 }
 
-func setDefaults(config provider_config.State) provider_config.State {
-	config.Endpoint = setToDefaultIfNeeded(config.Endpoint, "YC_ENDPOINT", common.DefaultEndpoint)
-	config.Token = setToDefaultIfNeeded(config.Token, "YC_TOKEN", "")
-	config.ServiceAccountKeyFileOrContent = setToDefaultIfNeeded(config.ServiceAccountKeyFileOrContent, "YC_SERVICE_ACCOUNT_KEY_FILE", "")
+// SetDefaults fills the provider settings missing in config from the YC_* environment variables
+// and the yc CLI profile. A profile is used as is: YC_TOKEN and YC_SERVICE_ACCOUNT_KEY_FILE don't override its credentials.
+func SetDefaults(config provider_config.State) provider_config.State {
+	config.Profile = setToDefaultIfNeeded(config.Profile, "YC_PROFILE", "")
+	config.Endpoint = setToDefaultIfNeeded(config.Endpoint, "YC_ENDPOINT", provider_config.ProfileEndpoint(config.Profile.ValueString(), common.DefaultEndpoint))
+	if config.Profile.ValueString() == "" {
+		config.Token = setToDefaultIfNeeded(config.Token, "YC_TOKEN", "")
+		config.ServiceAccountKeyFileOrContent = setToDefaultIfNeeded(config.ServiceAccountKeyFileOrContent, "YC_SERVICE_ACCOUNT_KEY_FILE", "")
+	}
 
 	if config.MaxRetries.IsUnknown() || config.MaxRetries.IsNull() {
 		config.MaxRetries = types.Int64Value(common.DefaultMaxRetries)
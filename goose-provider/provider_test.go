@@ -0,0 +1,64 @@
+package goose_provider
+
+import (
+	"regexp"
+	"testing"
+
+	provider_config "terraform-provider-goose/goose-provider/provider-config"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestSetDefaultsProfilePrecedence(t *testing.T) {
+	t.Setenv("YC_TOKEN", "t1.env.token")
+	t.Setenv("YC_SERVICE_ACCOUNT_KEY_FILE", "/env/key.json")
+	t.Setenv("YC_PROFILE", "")
+
+	state := SetDefaults(provider_config.State{})
+	if state.Token.ValueString() != "t1.env.token" || state.ServiceAccountKeyFileOrContent.ValueString() != "/env/key.json" {
+		t.Fatalf("the environment credentials aren't used without a profile: %v", state)
+	}
+
+	state = SetDefaults(provider_config.State{Profile: types.StringValue("prod")})
+	if state.Token.ValueString() != "" || state.ServiceAccountKeyFileOrContent.ValueString() != "" {
+		t.Fatalf("the environment credentials override the profile: %v", state)
+	}
+
+	t.Setenv("YC_PROFILE", "prod")
+	state = SetDefaults(provider_config.State{})
+	if state.Profile.ValueString() != "prod" || state.Token.ValueString() != "" {
+		t.Fatalf("the environment credentials override the YC_PROFILE profile: %v", state)
+	}
+
+	state = SetDefaults(provider_config.State{Token: types.StringValue("t1.explicit.token")})
+	if state.Token.ValueString() != "t1.explicit.token" {
+		t.Fatalf("the token of the provider block isn't kept: %v", state)
+	}
+}
+
+func TestAccProviderCredentialsConflict(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"goose": providerserver.NewProtocol6WithError(NewFrameworkProvider()),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "goose" {
+  token   = "t1.test.token"
+  profile = "prod"
+}
+
+data "goose_ydb_schema" "db" {
+  endpoint = "localhost"
+  database = "/local"
+}
+`,
+				ExpectError: regexp.MustCompile(`(?s)Invalid Attribute Combination.*profile`),
+			},
+		},
+	})
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"flag"
+	"os"
 
 	goose_provider "terraform-provider-goose/goose-provider"
+	goose_cli "terraform-provider-goose/goose-provider/goose-cli"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
@@ -29,6 +31,10 @@ func NewMuxProviderServer(ctx context.Context) (func() tfprotov6.ProviderServer,
 
 func main() {
 	ctx := context.Background()
+	if goose_cli.IsCommand(os.Args[1:]) {
+		os.Exit(goose_cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	var debug bool
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()